@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// budgets are the maximum summed adjusted execution times of the tests of each package from the -budgets file.
+var budgets map[string]time.Duration
+
+// loadBudgets reads the -budgets file, a JSON object of package paths to durations, such as {"github.com/org/repo/api":
+// "30s"}.
+func loadBudgets(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var durations map[string]string
+	if err = json.Unmarshal(data, &durations); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	budgets = make(map[string]time.Duration, len(durations))
+	for name, duration := range durations {
+		budget, err := time.ParseDuration(duration)
+		if err != nil {
+			return fmt.Errorf("%s: budget of %s: %w", path, name, err)
+		}
+		// Package names are redacted as they are read, so the budgets must match the redacted names
+		if redact {
+			name = redactName("pkg", name)
+		}
+		budgets[name] = budget
+	}
+	return nil
+}
+
+// packageTimes returns the summed adjusted execution time of the selected tests of each package.
+func packageTimes() map[string]time.Duration {
+	times := make(map[string]time.Duration)
+	for _, test := range filteredTests() {
+		times[test.Package] += test.AdjustedExecutionTime
+	}
+	return times
+}
+
+// overBudget returns the packages whose tests took longer than their budget, sorted by name.
+func overBudget() []string {
+	times := packageTimes()
+	var over []string
+	for name, budget := range budgets {
+		if times[name] > budget {
+			over = append(over, name)
+		}
+	}
+	sort.Strings(over)
+	return over
+}
+
+// printBudgets prints the summed adjusted execution time of each package with a budget, and how much of the budget
+// remains or by how much it was exceeded.
+func printBudgets() {
+	times := packageTimes()
+	names := make([]string, 0, len(budgets))
+	for name := range budgets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Println("\nPackage budgets:")
+	for _, name := range names {
+		actual, budget := times[name], budgets[name]
+		if actual > budget {
+			fmt.Printf("%s: %s of %s (%s over budget)\n", displayPackage(name), roundDuration(actual),
+				roundDuration(budget), roundDuration(actual-budget))
+		} else {
+			fmt.Printf("%s: %s of %s (%s remaining)\n", displayPackage(name), roundDuration(actual),
+				roundDuration(budget), roundDuration(budget-actual))
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// compare reports how the adjusted execution time of each test changed between two JSON reports.
+func compare(args []string) {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	tolerance := fs.String("tolerance", "0%", "only report changes larger than this percentage of the old time, e.g. 10%")
+	toleranceAbs := fs.Duration("tolerance-abs", 0, "only report changes larger than this duration, e.g. 50ms")
+	baselineDir := fs.String("baseline-dir", "",
+		"compare against the median adjusted time of each test across the JSON reports in this directory")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s compare [flags] old.json new.json\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "       %s compare -baseline-dir snapshots/ [flags] new.json\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "The reports are the output of -format json or -report-file.")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if (*baselineDir == "" && fs.NArg() != 2) || (*baselineDir != "" && fs.NArg() != 1) {
+		fs.Usage()
+		os.Exit(2)
+	}
+	tolerancePercent, err := strconv.ParseFloat(strings.TrimSuffix(*tolerance, "%"), 64)
+	if err != nil || tolerancePercent < 0 {
+		fmt.Fprintf(os.Stderr, "invalid -tolerance %q\n", *tolerance)
+		os.Exit(2)
+	}
+
+	var oldTimes map[string]time.Duration
+	if *baselineDir != "" {
+		oldTimes, err = loadMedianTimes(*baselineDir)
+	} else {
+		oldTimes, err = loadAdjustedTimes(fs.Arg(0))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	newTimes, err := loadAdjustedTimes(fs.Arg(fs.NArg() - 1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var changed, added, removed []string
+	for name, newTime := range newTimes {
+		oldTime, ok := oldTimes[name]
+		switch {
+		case !ok:
+			added = append(added, name)
+		case newTime.Round(time.Millisecond) != oldTime.Round(time.Millisecond) &&
+			exceedsTolerance(oldTime, newTime, tolerancePercent, *toleranceAbs):
+			changed = append(changed, name)
+		}
+	}
+	for name := range oldTimes {
+		if _, ok := newTimes[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool {
+		return newTimes[changed[i]]-oldTimes[changed[i]] > newTimes[changed[j]]-oldTimes[changed[j]]
+	})
+	sort.Strings(added)
+	sort.Strings(removed)
+
+	fmt.Println("Changed:")
+	for _, name := range changed {
+		oldTime, newTime := oldTimes[name].Round(time.Millisecond), newTimes[name].Round(time.Millisecond)
+		change := (newTime - oldTime).String()
+		if newTime > oldTime {
+			change = "+" + change
+		}
+		if oldTime > 0 {
+			fmt.Printf("%s: %s -> %s (%s, %+.1f%%)\n", name, oldTime, newTime, change,
+				100*float64(newTime-oldTime)/float64(oldTime))
+		} else {
+			fmt.Printf("%s: %s -> %s (%s)\n", name, oldTime, newTime, change)
+		}
+	}
+	if len(added) > 0 {
+		fmt.Println("\nNew:")
+		for _, name := range added {
+			fmt.Printf("%s: %s\n", name, newTimes[name].Round(time.Millisecond))
+		}
+	}
+	if len(removed) > 0 {
+		fmt.Println("\nRemoved:")
+		for _, name := range removed {
+			fmt.Println(name)
+		}
+	}
+}
+
+// exceedsTolerance reports whether the change from oldTime to newTime, in either direction, is larger than both
+// tolerancePercent of oldTime and toleranceAbs.
+func exceedsTolerance(oldTime, newTime time.Duration, tolerancePercent float64, toleranceAbs time.Duration) bool {
+	change := newTime - oldTime
+	if change < 0 {
+		change = -change
+	}
+	return change > toleranceAbs && float64(change) > tolerancePercent/100*float64(oldTime)
+}
+
+// loadAdjustedTimes reads a JSON report and returns the adjusted execution time of each test by package and name.
+func loadAdjustedTimes(path string) (map[string]time.Duration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var report JSONReport
+	if err = json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	unit, err := parseUnit(report.Unit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	times := make(map[string]time.Duration, len(report.Tests))
+	for _, test := range report.Tests {
+		times[test.Package+" "+test.Test] = time.Duration(test.Adjusted * float64(unit))
+	}
+	return times, nil
+}
+
+// loadMedianTimes reads all JSON reports in a directory and returns the median adjusted execution time of each test by
+// package and name, which is a more stable baseline than any single report. Tests count from the reports they are in.
+func loadMedianTimes(dir string) (map[string]time.Duration, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no JSON reports in %s", dir)
+	}
+	all := make(map[string][]time.Duration)
+	for _, path := range paths {
+		times, err := loadAdjustedTimes(path)
+		if err != nil {
+			return nil, err
+		}
+		for name, adjusted := range times {
+			all[name] = append(all[name], adjusted)
+		}
+	}
+	medians := make(map[string]time.Duration, len(all))
+	for name, times := range all {
+		slices.Sort(times)
+		middle := len(times) / 2
+		if len(times)%2 == 0 {
+			medians[name] = (times[middle-1] + times[middle]) / 2
+		} else {
+			medians[name] = times[middle]
+		}
+	}
+	return medians, nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// ConcurrencySample is the number of active tests from a point in the run until the next sample.
+type ConcurrencySample struct {
+	Time   time.Time
+	Active uint64
+}
+
+// concurrency is the number of active tests over time for -concurrency-csv, with a sample whenever it changed.
+var concurrency []ConcurrencySample
+
+// recordConcurrency adds a sample if the number of active tests changed since the last one. Changes at the same time,
+// such as a parent test handing over to its subtest, only keep the last count.
+func recordConcurrency(now time.Time) {
+	if n := len(concurrency); n > 0 && concurrency[n-1].Time.Equal(now) {
+		concurrency = concurrency[:n-1]
+	}
+	if len(concurrency) > 0 && concurrency[len(concurrency)-1].Active == activeTests {
+		return
+	}
+	concurrency = append(concurrency, ConcurrencySample{Time: now, Active: activeTests})
+}
+
+// writeConcurrencyCSV writes the number of active tests over time to the -concurrency-csv file, with the time in
+// seconds since the first event.
+func writeConcurrencyCSV() error {
+	file, err := os.Create(concurrencyCSV)
+	if err != nil {
+		return err
+	}
+	w := newCSVWriter(file)
+	// Write errors are reported by Flush
+	_ = w.Write([]string{"seconds", "active_tests"})
+	for _, sample := range concurrency {
+		_ = w.Write([]string{
+			strconv.FormatFloat(sample.Time.Sub(firstEventTime).Seconds(), 'f', 3, 64),
+			strconv.FormatUint(sample.Active, 10),
+		})
+	}
+	w.Flush()
+	if err = w.Error(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
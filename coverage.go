@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"time"
+)
+
+var coverageOutputRegexp = regexp.MustCompile(`coverage: (\d+(\.\d+)?%|\[no statements\])`)
+
+// coverageEnabled is set when the output shows the tests were run with -cover, which slows them down.
+var coverageEnabled bool
+
+// uncoveredTimes holds the adjusted execution times from the -coverage-baseline run, by package and test name.
+var uncoveredTimes map[string]time.Duration
+
+// loadCoverageBaseline processes a run without coverage and keeps its adjusted execution times, then resets the
+// state for the run being analyzed.
+func loadCoverageBaseline(path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	// The tests of the baseline are not part of the run being analyzed, so -stream must not write them
+	streaming := stream
+	stream = false
+	if err = processEvents(file, warnEventError); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+		os.Exit(1)
+	}
+	stream = streaming
+	uncoveredTimes = make(map[string]time.Duration, len(allTests))
+	for _, test := range allTests {
+		uncoveredTimes[test.Package+" "+test.Name] = test.AdjustedExecutionTime
+	}
+	resetState()
+}
+
+// printCoverageNote points out that timings include coverage instrumentation and, given a baseline, how much it
+// added to each test.
+func printCoverageNote() {
+	if !coverageEnabled {
+		return
+	}
+	fmt.Println("\nNote: tests ran with coverage enabled, so timings include instrumentation overhead.")
+	if uncoveredTimes == nil {
+		return
+	}
+
+	type overhead struct {
+		test     *RunningTest
+		overhead time.Duration
+	}
+	var overheads []overhead
+	for _, test := range allTests {
+		uncovered, ok := uncoveredTimes[test.Package+" "+test.Name]
+		if !ok {
+			continue
+		}
+		overheads = append(overheads, overhead{test: test, overhead: test.AdjustedExecutionTime - uncovered})
+	}
+	sort.Slice(overheads, func(i, j int) bool {
+		return overheads[i].overhead > overheads[j].overhead
+	})
+	if len(overheads) > topCount {
+		overheads = overheads[:topCount]
+	}
+
+	fmt.Println("\nCoverage overhead:")
+	for _, o := range overheads {
+		fmt.Printf("%s %s: %s\n", displayPackage(o.test.Package), o.test.Name, roundDuration(o.overhead))
+	}
+}
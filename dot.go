@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// writeDot writes the tree of the selected tests as a Graphviz digraph, with an edge from each parent test to each of
+// its subtests. Nodes are labeled with their adjusted time and filled with a more intense color the slower they are.
+func writeDot(w io.Writer) error {
+	var roots []*RunningTest
+	var slowest time.Duration
+	for _, test := range filteredTests() {
+		if test.Parent == nil || !includeTest(test.Parent) {
+			roots = append(roots, test)
+		}
+		slowest = max(slowest, test.AdjustedExecutionTime)
+	}
+	sortTests(roots)
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph tests {")
+	fmt.Fprintln(bw, "\tnode [shape=box, style=filled];")
+	for _, root := range roots {
+		writeDotNode(bw, root, slowest)
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// writeDotNode writes the node of a test and the nodes of its subtests, down to -max-depth, with edges to them.
+func writeDotNode(w io.Writer, test *RunningTest, slowest time.Duration) {
+	intensity := 0.0
+	if slowest > 0 {
+		intensity = float64(test.AdjustedExecutionTime) / float64(slowest)
+	}
+	// Shades of red from white for the fastest to full red for the slowest
+	fmt.Fprintf(w, "\t%s [label=%s, fillcolor=\"0.000 %.3f 1.000\"];\n", dotID(test),
+		strconv.Quote(test.Name+"\n"+roundDuration(test.AdjustedExecutionTime).String()), intensity)
+	if maxDepth >= 0 && testDepth(test.Name) >= maxDepth {
+		return
+	}
+	for _, child := range test.Children {
+		if !includeTest(child) {
+			continue
+		}
+		writeDotNode(w, child, slowest)
+		fmt.Fprintf(w, "\t%s -> %s;\n", dotID(test), dotID(child))
+	}
+}
+
+// dotID is the quoted ID of the node of a test, which is unique across packages.
+func dotID(test *RunningTest) string {
+	return strconv.Quote(test.Package + " " + test.Name)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	followPollInterval   = 250 * time.Millisecond
+	followRenderInterval = 2 * time.Second
+)
+
+var lastRender time.Time
+
+// followReader reads a file that is still being written, waiting for more data at EOF instead of returning it until
+// interrupted or stopped. If the file is truncated or replaced (log rotation), it starts over from the beginning of the
+// new file.
+type followReader struct {
+	path   string
+	file   *os.File
+	offset int64
+	// onIdle is called whenever the reader has caught up with the writer.
+	onIdle func()
+	// stopped is closed by stop to make Read return EOF the next time it waits for the writer.
+	stopped chan struct{}
+}
+
+func (f *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := f.file.Read(p)
+		f.offset += int64(n)
+		if n > 0 {
+			return n, nil
+		}
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if err = f.checkRotation(); err != nil {
+			return 0, err
+		}
+		f.onIdle()
+		select {
+		case <-interrupted:
+			return 0, io.EOF
+		case <-f.stopped:
+			return 0, io.EOF
+		case <-time.After(followPollInterval):
+		}
+	}
+}
+
+// stop makes Read return EOF instead of waiting for more data, such as after -stdin-timeout. It must not be called
+// concurrently.
+func (f *followReader) stop() {
+	select {
+	case <-f.stopped:
+	default:
+		close(f.stopped)
+	}
+}
+
+// checkRotation reopens the file if it was replaced and rewinds it if it was truncated.
+func (f *followReader) checkRotation() error {
+	current, err := f.file.Stat()
+	if err != nil {
+		return err
+	}
+	latest, err := os.Stat(f.path)
+	if err != nil {
+		// The file may be briefly missing while it is being rotated
+		return nil
+	}
+	if !os.SameFile(current, latest) {
+		file, err := os.Open(f.path)
+		if err != nil {
+			return nil
+		}
+		_ = f.file.Close()
+		f.file = file
+		f.offset = 0
+		resetState()
+		return nil
+	}
+	if latest.Size() < f.offset {
+		if _, err = f.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		f.offset = 0
+		resetState()
+	}
+	return nil
+}
+
+// renderFollow clears the terminal and prints the current results, at most once per render interval.
+func renderFollow() {
+	if time.Since(lastRender) < followRenderInterval {
+		return
+	}
+	lastRender = time.Now()
+	fmt.Print("\033[H\033[2J")
+	printReport()
+}
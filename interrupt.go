@@ -0,0 +1,81 @@
+package main
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// interrupted is closed on the first SIGINT, after which no more input is read so that the results so far are
+// printed. A second SIGINT exits right away.
+var interrupted = make(chan struct{})
+
+// stdinTimedOut is set once reading stopped because no input arrived for -stdin-timeout.
+var stdinTimedOut bool
+
+// notifyInterrupt starts catching SIGINT to close interrupted.
+func notifyInterrupt() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		<-signals
+		signal.Stop(signals)
+		close(interrupted)
+	}()
+}
+
+// interruptibleReader reads from reader until interrupted, or until no input arrived for -stdin-timeout, and then
+// returns EOF, even while a read from a pipe is blocked waiting for its writer.
+type interruptibleReader struct {
+	reader io.Reader
+}
+
+// stoppableReader is a reader whose Read can be made to return soon, such as followReader, which otherwise keeps
+// polling its file and rendering results in the background after the input was given up on.
+type stoppableReader interface {
+	io.Reader
+	stop()
+}
+
+// readResult is the outcome of a Read in the background.
+type readResult struct {
+	n   int
+	err error
+}
+
+func (r interruptibleReader) Read(p []byte) (int, error) {
+	// Read into a separate buffer, since a blocked read may complete after an interrupt returned
+	buf := make([]byte, len(p))
+	done := make(chan readResult, 1)
+	go func() {
+		n, err := r.reader.Read(buf)
+		done <- readResult{n, err}
+	}()
+	var timeout <-chan time.Time
+	if stdinTimeout > 0 {
+		timeout = time.After(stdinTimeout)
+	}
+	select {
+	case <-interrupted:
+		r.stop(done)
+		return 0, io.EOF
+	case <-timeout:
+		r.stop(done)
+		warnf("No input for %s, reporting partial results", stdinTimeout)
+		stdinTimedOut = true
+		return 0, io.EOF
+	case res := <-done:
+		copy(p, buf[:res.n])
+		return res.n, res.err
+	}
+}
+
+// stop waits for the read in the background to return if the reader can be stopped, so that it does not touch the
+// results while they are printed. Other readers, such as a pipe, may stay blocked until the program exits.
+func (r interruptibleReader) stop(done <-chan readResult) {
+	if reader, ok := r.reader.(stoppableReader); ok {
+		reader.stop()
+		<-done
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// jsonUnitName is the unit of the durations in JSON reports, which is the -unit unless that is auto.
+func jsonUnitName() string {
+	if displayUnit == unitAuto {
+		return "ms"
+	}
+	return displayUnit
+}
+
+// JSONReport is the JSON output of the analysis.
+type JSONReport struct {
+	// Unit of all durations in the report
+	Unit string `json:"unit"`
+	// Meta describes the run, such as its time, the host it was analyzed on, and the -meta flags
+	Meta map[string]string `json:"meta,omitempty"`
+	// Partial is set when only part of the input was analyzed, such as with -max-events or -stdin-timeout
+	Partial bool       `json:"partial,omitempty"`
+	Tests   []JSONTest `json:"tests"`
+}
+
+// JSONTest is a single test in the JSON output.
+type JSONTest struct {
+	Package   string  `json:"package"`
+	Test      string  `json:"test"`
+	Status    string  `json:"status,omitempty"`
+	Adjusted  float64 `json:"adjusted"`
+	Total     float64 `json:"total"`
+	WallClock float64 `json:"wall_clock"`
+	// Parallel is total over adjusted time, or zero if the adjusted time is zero
+	Parallel float64 `json:"parallel"`
+	// AverageActive is the time-weighted average number of active tests, including this one, that its time was
+	// divided by
+	AverageActive float64 `json:"average_active"`
+	// Merged is how many subtests -merge-numbered combined into this one, if more than one
+	Merged int `json:"merged,omitempty"`
+	// Attempts is how many times the test ran, if more than once, such as when it was retried after failing
+	Attempts int `json:"attempts,omitempty"`
+	// BytesPerOp and AllocsPerOp are reported by benchmarks run with -benchmem
+	BytesPerOp  int64 `json:"bytes_per_op,omitempty"`
+	AllocsPerOp int64 `json:"allocs_per_op,omitempty"`
+}
+
+// writeJSON writes all tests, in the given order, as a JSON report.
+func writeJSON(w io.Writer, tests []*RunningTest) error {
+	report := JSONReport{
+		Unit:    jsonUnitName(),
+		Meta:    runMeta(),
+		Partial: partialReason() != "",
+		Tests:   make([]JSONTest, 0, len(tests)),
+	}
+	for _, test := range tests {
+		report.Tests = append(report.Tests, jsonTest(test))
+	}
+	encoder := json.NewEncoder(w)
+	if jsonPretty {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder.Encode(report)
+}
+
+// jsonTest converts a test to its JSON output.
+func jsonTest(test *RunningTest) JSONTest {
+	return JSONTest{
+		Package:       test.Package,
+		Test:          test.Name,
+		Status:        test.Status,
+		Adjusted:      jsonDuration(test.AdjustedExecutionTime),
+		Total:         jsonDuration(test.TotalExecutionTime),
+		WallClock:     jsonDuration(test.WallClock),
+		Parallel:      parallelism(test),
+		AverageActive: averageActive(test),
+		Merged:        test.Merged,
+		Attempts:      attempts(test),
+		BytesPerOp:    test.BytesPerOp,
+		AllocsPerOp:   test.AllocsPerOp,
+	}
+}
+
+// streamTest writes a test to stdout as a line of JSON for -stream, as soon as it stopped, if it is selected for
+// reporting.
+func streamTest(test *RunningTest) {
+	if !includeTest(test) {
+		return
+	}
+	data, err := json.Marshal(jsonTest(test))
+	if err != nil {
+		return
+	}
+	// Like the rest of the output, write errors to stdout are not reported
+	_, _ = os.Stdout.Write(append(data, '\n'))
+}
+
+// attempts returns how many times a test ran, or zero if it only ran once.
+func attempts(test *RunningTest) int {
+	if len(test.Attempts) == 0 {
+		return 0
+	}
+	return len(test.Attempts) + 1
+}
+
+// JSONSummary is the aggregate numbers of the selected tests for -format summary-json, e.g. for dashboards.
+type JSONSummary struct {
+	// Unit of all durations in the summary
+	Unit string            `json:"unit"`
+	Meta map[string]string `json:"meta,omitempty"`
+	// Partial is set when only part of the input was analyzed, such as with -max-events or -stdin-timeout
+	Partial bool `json:"partial,omitempty"`
+	Tests   int  `json:"tests"`
+	Passed  int  `json:"passed"`
+	Failed  int  `json:"failed"`
+	Skipped int  `json:"skipped"`
+	// WallTime is from the first to the last event
+	WallTime float64 `json:"wall_time"`
+	Adjusted float64 `json:"adjusted"`
+	Total    float64 `json:"total"`
+	// SavedByParallelism is the total minus the adjusted time
+	SavedByParallelism float64 `json:"saved_by_parallelism"`
+	PeakConcurrency    uint64  `json:"peak_concurrency"`
+	// PeakTests are the tests, as package and name, that were running at the peak concurrency
+	PeakTests []string `json:"peak_tests,omitempty"`
+	Slowest   string   `json:"slowest,omitempty"`
+	// SlowestAdjusted is the adjusted time of the slowest test
+	SlowestAdjusted float64 `json:"slowest_adjusted"`
+}
+
+// writeSummaryJSON writes the aggregate numbers of the selected tests as a JSON object.
+func writeSummaryJSON(w io.Writer) error {
+	summary := JSONSummary{
+		Unit:            jsonUnitName(),
+		Meta:            runMeta(),
+		Partial:         partialReason() != "",
+		WallTime:        jsonDuration(lastEventTime.Sub(firstEventTime)),
+		PeakConcurrency: peakConcurrency,
+	}
+	var adjusted, total time.Duration
+	var slowest *RunningTest
+	for _, test := range filteredTests() {
+		summary.Tests++
+		switch {
+		case test.Status == "pass":
+			summary.Passed++
+		case test.Status == "skip":
+			summary.Skipped++
+		case failed(test):
+			summary.Failed++
+		}
+		adjusted += test.AdjustedExecutionTime
+		total += test.TotalExecutionTime
+		if slowest == nil || test.AdjustedExecutionTime > slowest.AdjustedExecutionTime {
+			slowest = test
+		}
+	}
+	for _, test := range sortedPeakTests() {
+		summary.PeakTests = append(summary.PeakTests, test.Package+" "+test.Name)
+	}
+	summary.Adjusted = jsonDuration(adjusted)
+	summary.Total = jsonDuration(total)
+	summary.SavedByParallelism = jsonDuration(total - adjusted)
+	if slowest != nil {
+		summary.Slowest = slowest.Package + " " + slowest.Name
+		summary.SlowestAdjusted = jsonDuration(slowest.AdjustedExecutionTime)
+	}
+
+	encoder := json.NewEncoder(w)
+	if jsonPretty {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder.Encode(summary)
+}
+
+// JSONStatus is the headline numbers of a run that -status-file writes for CI scripts.
+type JSONStatus struct {
+	// Unit of all durations in the status
+	Unit     string `json:"unit"`
+	Tests    int    `json:"tests"`
+	Failures int    `json:"failures"`
+	Slowest  string `json:"slowest,omitempty"`
+	// SlowestAdjusted is the adjusted time of the slowest test
+	SlowestAdjusted float64 `json:"slowest_adjusted"`
+	// ThresholdBreached is whether a test was over -fail-over or -fail-over-percentile, or a package over its -budgets
+	ThresholdBreached bool `json:"threshold_breached"`
+}
+
+// writeStatusFile writes the headline numbers of the selected tests to the -status-file as JSON.
+func writeStatusFile(thresholdBreached bool) error {
+	status := JSONStatus{Unit: jsonUnitName(), ThresholdBreached: thresholdBreached}
+	var slowest *RunningTest
+	for _, test := range filteredTests() {
+		status.Tests++
+		if failed(test) {
+			status.Failures++
+		}
+		if slowest == nil || test.AdjustedExecutionTime > slowest.AdjustedExecutionTime {
+			slowest = test
+		}
+	}
+	if slowest != nil {
+		status.Slowest = slowest.Package + " " + slowest.Name
+		status.SlowestAdjusted = jsonDuration(slowest.AdjustedExecutionTime)
+	}
+
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(statusFile, append(data, '\n'), 0o644)
+}
+
+// runMeta returns the -meta flags, along with the time of the first event and the hostname of this machine unless they
+// were given. -normalize-time leaves out the time and the hostname, which differ between otherwise identical reports.
+func runMeta() map[string]string {
+	result := make(map[string]string, len(meta)+2)
+	if !firstEventTime.IsZero() && !normalizeTime {
+		result["time"] = firstEventTime.Format(time.RFC3339)
+	}
+	// The hostname could identify the organization that -redact hides
+	if hostname, err := os.Hostname(); err == nil && !redact && !normalizeTime {
+		result["hostname"] = hostname
+	}
+	for key, value := range meta {
+		result[key] = value
+	}
+	return result
+}
+
+// jsonDuration converts a duration to a number in the unit of the JSON report.
+func jsonDuration(d time.Duration) float64 {
+	unit, _ := parseUnit(jsonUnitName())
+	return float64(d) / float64(unit)
+}
+
+// parseUnit returns the duration of a unit named in a JSON report or by -unit.
+func parseUnit(name string) (time.Duration, error) {
+	switch name {
+	case "ns":
+		return time.Nanosecond, nil
+	case "us":
+		return time.Microsecond, nil
+	case "ms":
+		return time.Millisecond, nil
+	case "s":
+		return time.Second, nil
+	}
+	return 0, fmt.Errorf("unknown unit %q", name)
+}
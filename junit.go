@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JUnitReport is the JUnit XML output of the analysis, with a test suite for each package.
+type JUnitReport struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite is the tests of a package in the JUnit XML output.
+type JUnitTestSuite struct {
+	Name     string `xml:"name,attr"`
+	Tests    int    `xml:"tests,attr"`
+	Failures int    `xml:"failures,attr"`
+	Skipped  int    `xml:"skipped,attr"`
+	// Time is the summed adjusted time of the tests, in seconds
+	Time      string          `xml:"time,attr"`
+	TestCases []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase is a single test in the JUnit XML output.
+type JUnitTestCase struct {
+	ClassName string `xml:"classname,attr"`
+	Name      string `xml:"name,attr"`
+	// Time is the adjusted time of the test, in seconds, so that the times of all tests add up to the time of the run
+	Time    string        `xml:"time,attr"`
+	Failure *JUnitFailure `xml:"failure,omitempty"`
+	Skipped *struct{}     `xml:"skipped,omitempty"`
+}
+
+// JUnitFailure is how a test failed, with its output if -include-output kept it.
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Output  string `xml:",chardata"`
+}
+
+// writeJUnit writes all tests as JUnit XML for CI systems that display test reports, with the packages in the order
+// of their first test and the tests of each package in the given order.
+func writeJUnit(w io.Writer, tests []*RunningTest) error {
+	var report JUnitReport
+	suites := make(map[string]int)
+	suiteTimes := make(map[string]time.Duration)
+	for _, test := range tests {
+		i, ok := suites[test.Package]
+		if !ok {
+			i = len(report.Suites)
+			suites[test.Package] = i
+			report.Suites = append(report.Suites, JUnitTestSuite{Name: test.Package})
+		}
+		suite := &report.Suites[i]
+		testCase := JUnitTestCase{
+			ClassName: test.Package,
+			Name:      test.Name,
+			Time:      junitSeconds(test.AdjustedExecutionTime),
+		}
+		switch {
+		case failed(test):
+			testCase.Failure = &JUnitFailure{Message: test.Status, Output: strings.Join(test.Output, "")}
+			suite.Failures++
+		case test.Status == "skip":
+			testCase.Skipped = &struct{}{}
+			suite.Skipped++
+		}
+		suite.Tests++
+		suite.TestCases = append(suite.TestCases, testCase)
+		suiteTimes[test.Package] += test.AdjustedExecutionTime
+	}
+	for i := range report.Suites {
+		report.Suites[i].Time = junitSeconds(suiteTimes[report.Suites[i].Name])
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// junitSeconds formats a duration in seconds, the unit of JUnit XML regardless of -unit.
+func junitSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
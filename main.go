@@ -3,180 +3,1160 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"regexp"
 	"sort"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 const resultsToList = 50
 
+// Values of -totals, which controls when the text output shows total execution time
+const (
+	totalsAuto   = "auto"
+	totalsAlways = "always"
+	totalsNever  = "never"
+)
+
+// Values of -by, which selects what the results are listed by
+const (
+	groupByTest    = "test"
+	groupByPackage = "package"
+)
+
+// Values of -sort, which selects what the tests are ranked by
+const (
+	sortByAdjusted = "adjusted"
+	sortByEnd      = "end"
+)
+
+// Values of -orphan-pause, which selects how to handle the pause of a test that is not running
+const (
+	orphanPauseWarn   = "warn"
+	orphanPauseIgnore = "ignore"
+	orphanPauseError  = "error"
+)
+
+// unitAuto is the -unit that rounds each duration according to its size instead of to a fixed unit
+const unitAuto = "auto"
+
+// Output formats
+const (
+	formatText     = "text"
+	formatMarkdown = "markdown"
+	formatJSON     = "json"
+	formatDot      = "dot"
+	formatJUnit    = "junit"
+	// formatSummaryJSON is only the aggregate numbers of the run, as a JSON object
+	formatSummaryJSON = "summary-json"
+	formatTSV         = "tsv"
+)
+
 type Event struct {
 	Time    time.Time `json:"Time"`
 	Action  string    `json:"Action"`
 	Test    string    `json:"Test"`
 	Package string    `json:"Package"`
+	Elapsed float64   `json:"Elapsed"`
+	Output  string    `json:"Output"`
 }
 
+// Test statuses in addition to the pass, fail, and skip actions reported by go test
+const (
+	statusPanic   = "panic"
+	statusTimeout = "timeout"
+)
+
 type RunningTest struct {
 	Name                  string
 	Package               string
+	StartTime             time.Time
+	EndTime               time.Time
 	LastTimestamp         time.Time
 	AdjustedExecutionTime time.Duration
 	TotalExecutionTime    time.Duration
-	Children              []*RunningTest
+	WallClock             time.Duration // from run to result, regardless of pauses and parallelism
+	RunningChildren       []*RunningTest
+	Children              []*RunningTest // all subtests, kept after they stop
 	Parent                *RunningTest
 	AssumedStopped        bool
 	Parallel              bool
+	// WaitingForSubtests is set once the test function has returned and the test only waits for parallel subtests
+	WaitingForSubtests bool
+	Status             string
+	// Output is the output of the test with -include-output, to be printed if it fails
+	Output []string
+	// Merged is how many subtests with Go-generated #NN suffixes -merge-numbered combined into this one
+	Merged int
+	// NsPerOp, BytesPerOp, and AllocsPerOp are the result of a benchmark run with -benchmem, if MemoryReported
+	NsPerOp        float64
+	BytesPerOp     int64
+	AllocsPerOp    int64
+	MemoryReported bool
+	// Attempts are the earlier runs of the same test, oldest first, when it ran more than once
+	Attempts []*RunningTest
+	// ActiveSeconds adds up the number of active tests over the execution time of the test, in seconds, for the
+	// time-weighted average number of tests it shared its time with
+	ActiveSeconds float64
+	// OutputEvents is how many output events the test had between its run and its result, besides the lines go test
+	// adds, such as === RUN
+	OutputEvents int
+	// OverlappedTime is the part of the execution time of the test during which other tests were active too
+	OverlappedTime time.Duration
 }
 
-var subTestRegexp = regexp.MustCompile("^(?P<parent>\\S+)/\\S+$")
-
 // Pre-allocate some memory for the tests
 var allTests = make(map[string]*RunningTest, 1000)
 var runningTests = make(map[string]*RunningTest, 10)
 
+// activeTests is the number of running tests that are not assumed stopped. It is maintained by setRunning,
+// removeRunning, and setAssumedStopped so that it does not need to be recounted on every event.
+var activeTests uint64
+
+// The highest number of active tests, when it was first reached, and the tests that were active at that moment
+var peakConcurrency uint64
+var peakTime time.Time
+var peakTests []*RunningTest
+
+// Time with no active tests between one test stopping and the next one starting, and since when no test is active
+var idleTime time.Duration
+var idleSince time.Time
+
+// TimelineSample is the longest-running active test at a sampled point in time.
+type TimelineSample struct {
+	Time    time.Time
+	Test    *RunningTest
+	Running time.Duration
+}
+
+// PackageResult is the outcome of a whole test package, taken from package-level events.
+type PackageResult struct {
+	Name      string
+	Status    string
+	StartTime time.Time
+	Elapsed   time.Duration
+	TimedOut  bool
+}
+
+var packages = make(map[string]*PackageResult, 100)
+
+// testEvents is the number of events for individual tests, used to detect input that is not from go test -json.
+var testEvents int
+
+// processedEvents is the number of events processed so far, and stoppedAtMaxEvents is set once -max-events stopped
+// processing before the end of the input.
+var processedEvents int
+var stoppedAtMaxEvents bool
+
+// droppedTests counts the events of tests that were left out because there were already -max-tests tests.
+var droppedTests int
+
+// framedOutput is set once any test had the === RUN or --- PASS lines go test adds, which shows that the input kept the
+// output events.
+var framedOutput bool
+
+// diagnostics are the input lines that were not go test -json events, in the order they were read.
+var diagnostics []string
+
+var timelineInterval time.Duration
+var packageOnly bool
+var showOverhead bool
+var follow bool
+var outputFormat string
+var prefixDelimiter string
+var coverageBaseline string
+var budgetsFile string
+var reverse bool
+var failuresFirst bool
+var maxDepth int
+var outlierThreshold float64
+var totalsMode string
+var groupBy string
+var showParallelism bool
+var showAverageActive bool
+var verboseColumns bool
+var groupIdentical bool
+var showZeroDuration bool
+var selectedTest string
+var brief bool
+var noWarnings bool
+
+// suppressedWarnings counts the warnings that -no-warnings kept from being printed.
+var suppressedWarnings int
+var failOver time.Duration
+var reportFile string
+var emit emitFlag
+var reportFormat string
+var showTree bool
+var displayUnit string
+var pauseWindowTest string
+var jsonPretty bool
+var normalizeTime bool
+var printSchema bool
+var stdinTimeout time.Duration
+var redact bool
+var minPackageTime time.Duration
+var countByPackage bool
+var includeOutput bool
+var concurrencyCSV string
+var summaryOnly bool
+var compact bool
+var allowIncomplete bool
+var reconcileThreshold float64
+var failOverPercentile float64
+var failOverFactor float64
+var mergeNumbered bool
+var testNamePatterns regexpsFlag
+var amortize bool
+var weightedByCount bool
+var topPerPackage int
+var sortBy string
+var topCount int
+var traceCSV string
+var csvDelimiter string
+var slowPasses time.Duration
+var singleSubtests bool
+var deepNesting int
+var excludeSubtestPatterns regexpsFlag
+var statusFile string
+var propagateStatus bool
+var maxEvents int
+var maxTests int
+var pareto bool
+var contention bool
+var ranAlone bool
+var rerunDetection bool
+var minSamples int
+var silentTests bool
+var orphanPause string
+var stream bool
+var noAssumeStopped bool
+var meta = make(metaFlag)
+var onlyPackages = make(packagesFlag)
+var trimPrefix string
+var timeline []TimelineSample
+var firstEventTime, lastEventTime, nextSampleTime time.Time
+
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "analyze":
+			analyze(os.Args[2:])
+			return
+		case "compare":
+			compare(os.Args[2:])
+			return
+		case "serve":
+			serve(os.Args[2:])
+			return
+		case "trend":
+			trend(os.Args[2:])
+			return
+		}
+	}
+	// Without a subcommand, analyze like before subcommands were added
+	analyze(os.Args[1:])
+}
+
+// regexpsFlag is a flag that can be repeated to collect several regular expressions.
+type regexpsFlag []*regexp.Regexp
+
+func (f *regexpsFlag) String() string {
+	patterns := make([]string, 0, len(*f))
+	for _, re := range *f {
+		patterns = append(patterns, re.String())
+	}
+	return strings.Join(patterns, ", ")
+}
+
+func (f *regexpsFlag) Set(pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, re)
+	return nil
+}
+
+// emitFlag is a flag that can be repeated to collect format=path pairs of reports to write.
+type emitFlag []emitTarget
+
+// emitTarget is a report to write in a format to a file.
+type emitTarget struct {
+	format string
+	path   string
+}
+
+func (f *emitFlag) String() string {
+	pairs := make([]string, 0, len(*f))
+	for _, target := range *f {
+		pairs = append(pairs, target.format+"="+target.path)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (f *emitFlag) Set(pair string) error {
+	format, path, ok := strings.Cut(pair, "=")
+	if !ok || path == "" {
+		return fmt.Errorf("%q is not format=path", pair)
+	}
+	if !knownFormat(format) {
+		return fmt.Errorf("unknown format %q", format)
+	}
+	*f = append(*f, emitTarget{format: format, path: path})
+	return nil
+}
+
+// packagesFlag is a flag that can be repeated to collect comma-separated lists of package paths.
+type packagesFlag map[string]bool
+
+func (f packagesFlag) String() string {
+	names := make([]string, 0, len(f))
+	for name := range f {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func (f packagesFlag) Set(list string) error {
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			f[name] = true
+		}
+	}
+	return nil
+}
+
+// metaFlag is a flag that can be repeated to collect key=value pairs.
+type metaFlag map[string]string
+
+func (f metaFlag) String() string {
+	pairs := make([]string, 0, len(f))
+	for key, value := range f {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (f metaFlag) Set(pair string) error {
+	key, value, ok := strings.Cut(pair, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("%q is not key=value", pair)
+	}
+	f[key] = value
+	return nil
+}
 
-	reader := bufio.NewReader(os.Stdin)
+// addAnalysisFlags adds the flags that control how events are analyzed and reported.
+func addAnalysisFlags(fs *flag.FlagSet) {
+	fs.DurationVar(&timelineInterval, "timeline", 0,
+		"sample the longest-running active test at this interval (e.g. 1s) and print a timeline")
+	fs.BoolVar(&packageOnly, "package-only", false,
+		"only process package-level events and report package outcomes and durations")
+	fs.BoolVar(&showOverhead, "overhead", false,
+		"report setup/teardown time of parent tests relative to the time spent in their subtests")
+	fs.StringVar(&prefixDelimiter, "group-by-prefix", "",
+		"group tests by the part of their name before this delimiter (e.g. _) and rank the groups by adjusted time")
+	fs.BoolVar(&amortize, "amortize", false,
+		"add an even share of each parent test's own time, such as shared setup, to the time of each of its subtests")
+	fs.BoolVar(&weightedByCount, "weighted-by-count", false,
+		"rank tests by their time summed over all their runs, such as with go test -count, instead of their last run")
+	fs.BoolVar(&mergeNumbered, "merge-numbered", false,
+		"merge subtests whose names only differ by the #01, #02, ... suffixes go test adds to duplicate names")
+	fs.IntVar(&maxDepth, "max-depth", -1,
+		"fold subtests nested deeper than this into their ancestor at this depth, where 0 is top-level tests only")
+	fs.Float64Var(&outlierThreshold, "outliers", 0,
+		"report tests whose adjusted time is more than this many standard deviations above their package mean")
+	fs.Float64Var(&reconcileThreshold, "reconcile", 0,
+		"flag packages whose summed adjusted test time differs from their elapsed time by more than this fraction, e.g. 0.2")
+	fs.IntVar(&maxEvents, "max-events", 0,
+		"stop after this many events and report on them, e.g. for a quick look at a huge log")
+	fs.IntVar(&maxTests, "max-tests", 0,
+		"stop adding new tests after this many, to bound the memory used by corrupt logs that invent many test names")
+	fs.BoolVar(&noAssumeStopped, "no-assume-stopped", false,
+		"only stop a subtest on its result, instead of assuming it finished when a serial sibling starts")
+	fs.BoolVar(&allowIncomplete, "allow-incomplete", false,
+		"do not warn about tests that are still running at the end, e.g. when analyzing a partial log on purpose")
+	fs.BoolVar(&summaryOnly, "summary-only", false,
+		"print only aggregate numbers such as test counts, wall time, and peak concurrency instead of the list of tests")
+	fs.BoolVar(&compact, "compact", false,
+		"print one line per package with its number of tests, summed adjusted time, and slowest test")
+	fs.BoolVar(&brief, "brief", false, "print a single summary line and no warnings, e.g. for commit hooks")
+	fs.BoolVar(&noWarnings, "no-warnings", false,
+		"print no warnings or other messages besides fatal errors, and exit with status 1 if there would have been any")
+	fs.DurationVar(&failOver, "fail-over", 0, "exit with status 1 if any test's adjusted time is over this duration")
+	fs.Float64Var(&failOverPercentile, "fail-over-percentile", 0,
+		"exit with status 1 if any test's adjusted time is over -fail-over-factor times this percentile of all tests")
+	fs.Float64Var(&failOverFactor, "fail-over-factor", 2, "factor of -fail-over-percentile that a test may take")
+	fs.StringVar(&selectedTest, "test", "", "only report this test and its subtests")
+	fs.DurationVar(&slowPasses, "slow-passes", 0,
+		"only report tests that passed and whose adjusted time is over this duration, leaving out failures and skips")
+	fs.Var(&excludeSubtestPatterns, "exclude-subtest",
+		"leave out subtests whose full name matches the regular expression `pattern`, and their subtests; can be repeated")
+	fs.Var(&testNamePatterns, "test-name",
+		"only report tests whose name matches the regular expression `pattern`; repeat to report tests matching any of them")
+	fs.Var(onlyPackages, "only-packages",
+		"only report tests of these comma-separated `packages`, matched exactly instead of by pattern; can be repeated")
+	fs.StringVar(&pauseWindowTest, "pause-window", "",
+		"list the tests that were running while this parallel test was paused, waiting for its turn")
+	fs.StringVar(&sortBy, "sort", sortByAdjusted,
+		"rank tests by adjusted time, slowest first, or by end time, last to finish first: adjusted or end")
+	fs.IntVar(&topCount, "top", resultsToList,
+		"number of tests to list in text and markdown output, including with -overhead")
+	fs.StringVar(&orphanPause, "orphan-pause", orphanPauseWarn,
+		"how to handle the pause of a test that is not running, which can be benign in merged logs: warn, ignore, or error")
+	fs.BoolVar(&reverse, "reverse", false, "reverse the sort order of the results")
+	fs.BoolVar(&failuresFirst, "failures-first", false,
+		"list failed tests before the others, each sorted as usual, e.g. to triage a failed run")
+	fs.IntVar(&topPerPackage, "top-per-package", 0,
+		"list the slowest this many tests of each package, grouped by package, instead of the slowest tests overall")
+	fs.BoolVar(&showTree, "show-tree", false,
+		"also print all tests as a tree of subtests, with children sorted by adjusted time")
+	fs.StringVar(&totalsMode, "totals", totalsAuto,
+		"when to show total time and parallelism in text output: auto (only when it differs), always, or never")
+	fs.BoolVar(&showParallelism, "p", false,
+		"always show the parallelism factor (total over adjusted time) as a decimal number")
+	fs.BoolVar(&showAverageActive, "average-active", false,
+		"show the average number of tests that were active while each test ran, which its time was divided by")
+	fs.BoolVar(&verboseColumns, "verbose-columns", false,
+		"always show the wall clock, adjusted, and total time of each test in text output, each with a label")
+	fs.BoolVar(&groupIdentical, "group-identical", false,
+		"collapse consecutive tests with the same rounded adjusted time into one line with their count in text output")
+	fs.BoolVar(&showZeroDuration, "show-zero-duration", false,
+		"also list tests whose adjusted time rounds to zero milliseconds, e.g. with -unit us to see their time")
+	fs.StringVar(&groupBy, "by", groupByTest,
+		"list results by test or by package, where packages show their first and last test to finish")
+	fs.BoolVar(&singleSubtests, "single-subtests", false,
+		"report tests with exactly one subtest, where t.Run may add overhead without grouping anything")
+	fs.IntVar(&deepNesting, "deep-nesting", 0,
+		"list the subtests nested more than this many levels deep, which are often hard to maintain")
+	fs.BoolVar(&pareto, "pareto", false,
+		"list the slowest tests with the cumulative percentage of the total adjusted time they account for")
+	fs.BoolVar(&contention, "contention", false,
+		"list the tests that shared their execution time with the most other tests, whose time was divided the most")
+	fs.BoolVar(&ranAlone, "ran-alone", false,
+		"classify the tests by whether they ever ran alone or always alongside others, which divided all of their time")
+	fs.BoolVar(&rerunDetection, "rerun-detection", false,
+		"list the tests that ran more than once with different outcomes, such as both fail and pass, as flaky")
+	fs.IntVar(&minSamples, "min-samples", 1,
+		"only report retries, inconsistent outcomes, and summed runs for tests that ran at least this many times")
+	fs.BoolVar(&silentTests, "silent-tests", false,
+		"list the tests that passed without any output events, which may not have run their body")
+	fs.BoolVar(&countByPackage, "count-by-package", false,
+		"report how many tests and subtests each package has, the package with the most tests first")
+	fs.DurationVar(&minPackageTime, "min-package-time", 0,
+		"with -by package, leave out packages whose summed adjusted time is below this duration")
+	fs.StringVar(&trimPrefix, "trim-prefix", "",
+		"remove this prefix, such as github.com/org/repo/, from package names in text and markdown output")
+	fs.StringVar(&outputFormat, "format", formatText,
+		"output format: text, markdown, json, tsv, junit (XML for CI), dot (a Graphviz graph of the test tree), "+
+			"or summary-json (only totals)")
+	fs.BoolVar(&includeOutput, "include-output", false,
+		"print the output of failed tests, which keeps the output of every test in memory")
+	fs.Var(meta, "meta",
+		"add `key=value` to the header of JSON output, e.g. commit=$(git rev-parse HEAD); can be repeated")
+	fs.BoolVar(&jsonPretty, "json-pretty", false, "indent JSON output to make it easier to read")
+	fs.BoolVar(&normalizeTime, "normalize-time", false,
+		"leave the time of the run and the hostname out of JSON output, so that reports of the same input are identical")
+	fs.BoolVar(&redact, "redact", false,
+		"replace package and test names with stable hashes and drop non-event lines, e.g. to share the results")
+	fs.StringVar(&displayUnit, "unit", "ms",
+		"round durations to ns, us, ms, or s, or auto to round each according to its size; also the unit of JSON output")
+}
+
+// checkAnalysisFlags exits if a flag added by addAnalysisFlags has an invalid value.
+func checkAnalysisFlags() {
+	checkFormat(outputFormat)
+	switch groupBy {
+	case groupByTest, groupByPackage:
+	default:
+		fmt.Fprintf(os.Stderr, "unknown -by %q\n", groupBy)
+		os.Exit(2)
+	}
+	switch sortBy {
+	case sortByAdjusted, sortByEnd:
+	default:
+		fmt.Fprintf(os.Stderr, "unknown -sort %q\n", sortBy)
+		os.Exit(2)
+	}
+	switch totalsMode {
+	case totalsAuto, totalsAlways, totalsNever:
+	default:
+		fmt.Fprintf(os.Stderr, "unknown -totals %q\n", totalsMode)
+		os.Exit(2)
+	}
+	switch orphanPause {
+	case orphanPauseWarn, orphanPauseIgnore, orphanPauseError:
+	default:
+		fmt.Fprintf(os.Stderr, "unknown -orphan-pause %q\n", orphanPause)
+		os.Exit(2)
+	}
+	if topCount < 1 {
+		fmt.Fprintf(os.Stderr, "-top %d is not a positive number of tests\n", topCount)
+		os.Exit(2)
+	}
+	if minSamples < 1 {
+		fmt.Fprintf(os.Stderr, "-min-samples %d is not a positive number of runs\n", minSamples)
+		os.Exit(2)
+	}
+	if failOverPercentile < 0 || failOverPercentile > 100 {
+		fmt.Fprintf(os.Stderr, "-fail-over-percentile %v is not between 0 and 100\n", failOverPercentile)
+		os.Exit(2)
+	}
+	if _, err := parseUnit(displayUnit); err != nil && displayUnit != unitAuto {
+		fmt.Fprintf(os.Stderr, "unknown -unit %q\n", displayUnit)
+		os.Exit(2)
+	}
+	if redact && includeOutput {
+		// The output of a test would show what -redact hides, such as its name in === RUN lines
+		fmt.Fprintln(os.Stderr, "-include-output cannot be used with -redact")
+		os.Exit(2)
+	}
+	if redact && (len(testNamePatterns) > 0 || len(excludeSubtestPatterns) > 0) {
+		// Test names are redacted as they are read, so regular expressions of the real names would match none of them
+		fmt.Fprintln(os.Stderr, "-test-name and -exclude-subtest cannot be used with -redact")
+		os.Exit(2)
+	}
+	if redact && (trimPrefix != "" || prefixDelimiter != "") {
+		// Redacted names are hashes, which neither start with the real prefix nor contain the delimiter
+		fmt.Fprintln(os.Stderr, "-trim-prefix and -group-by-prefix cannot be used with -redact")
+		os.Exit(2)
+	}
+	if redact {
+		// Flags that name tests take the real names
+		if selectedTest != "" {
+			selectedTest = redactTestName(selectedTest)
+		}
+		if pauseWindowTest != "" {
+			pauseWindowTest = redactTestName(pauseWindowTest)
+		}
+		redacted := make(packagesFlag, len(onlyPackages))
+		for name := range onlyPackages {
+			redacted[redactName("pkg", name)] = true
+		}
+		onlyPackages = redacted
+	}
+}
+
+// checkCSVDelimiter exits if -csv-delimiter is not a single character that can separate CSV fields.
+func checkCSVDelimiter() {
+	delimiter, size := utf8.DecodeRuneInString(csvDelimiter)
+	valid := delimiter != 0 && delimiter != utf8.RuneError && utf8.ValidRune(delimiter)
+	if size != len(csvDelimiter) || !valid || strings.ContainsRune("\"\r\n", delimiter) {
+		fmt.Fprintf(os.Stderr, "-csv-delimiter %q is not a single character other than NUL, a quote, or newline\n",
+			csvDelimiter)
+		os.Exit(2)
+	}
+}
+
+// checkFormat exits if format is not a known output format.
+func checkFormat(format string) {
+	if !knownFormat(format) {
+		fmt.Fprintf(os.Stderr, "unknown format %q\n", format)
+		os.Exit(2)
+	}
+}
+
+// knownFormat reports whether format is one of the output formats.
+func knownFormat(format string) bool {
+	switch format {
+	case formatText, formatMarkdown, formatJSON, formatTSV, formatJUnit, formatDot, formatSummaryJSON:
+		return true
+	}
+	return false
+}
+
+// analyze processes go test -json output from a file or stdin and reports the results.
+func analyze(args []string) {
+	fs := flag.NewFlagSet("analyze", flag.ExitOnError)
+	addAnalysisFlags(fs)
+	fs.BoolVar(&follow, "follow", false,
+		"keep reading the input file as it grows, like tail -f, and periodically re-render the results")
+	fs.StringVar(&coverageBaseline, "coverage-baseline", "",
+		"go test -json output of the same tests run without -cover, to report the per-test coverage overhead")
+	fs.StringVar(&budgetsFile, "budgets", "",
+		"JSON file of package paths to the most adjusted time their tests may take; exit with status 1 if any is over")
+	fs.StringVar(&reportFile, "report-file", "",
+		"also write the results to this file, in -report-format, while printing the usual output")
+	fs.StringVar(&reportFormat, "report-format", formatJSON,
+		"format of the -report-file: text, markdown, json, tsv, junit, dot, or summary-json")
+	fs.Var(&emit, "emit",
+		"also write the results to a file in a format, given as `format=path`, e.g. json=report.json; can be repeated")
+	fs.StringVar(&statusFile, "status-file", "",
+		"write the number of tests and failures, the slowest test, and whether -fail-over was breached to this JSON file")
+	fs.BoolVar(&propagateStatus, "propagate-status", false,
+		"exit with status 1 if any test or package failed, like go test, after printing the results")
+	fs.StringVar(&concurrencyCSV, "concurrency-csv", "",
+		"write the number of active tests over time to this CSV file, e.g. to plot it in a spreadsheet")
+	fs.BoolVar(&printSchema, "print-schema", false, "print the JSON Schema of JSON output and exit")
+	fs.DurationVar(&stdinTimeout, "stdin-timeout", 0,
+		"stop reading and report the results so far if no input arrives for this long, such as when go test hangs")
+	fs.BoolVar(&stream, "stream", false,
+		"write each test as a line of JSON as soon as it finishes, before the usual output at the end of the input")
+	fs.StringVar(&traceCSV, "trace-csv", "",
+		"write a row per event to this CSV file with the number of active tests and the adjusted time of its test so far")
+	fs.StringVar(&csvDelimiter, "csv-delimiter", ",",
+		"field separator of the -concurrency-csv and -trace-csv files, such as ; for spreadsheets in some locales")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [analyze] [flags] [file]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if printSchema {
+		if err := writeSchema(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	checkAnalysisFlags()
+	checkFormat(reportFormat)
+	checkCSVDelimiter()
+
+	var input io.Reader = os.Stdin
+	if fs.NArg() > 0 {
+		file, err := os.Open(fs.Arg(0))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		input = file
+		if follow {
+			input = &followReader{path: fs.Arg(0), file: file, onIdle: renderFollow, stopped: make(chan struct{})}
+		}
+	} else if follow {
+		fmt.Fprintln(os.Stderr, "-follow requires a file argument")
+		os.Exit(1)
+	}
+	if coverageBaseline != "" {
+		loadCoverageBaseline(coverageBaseline)
+	}
+	if budgetsFile != "" {
+		if err := loadBudgets(budgetsFile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	if traceCSV != "" {
+		if err := openTrace(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	// On Ctrl-C, stop reading and print the results so far
+	notifyInterrupt()
+	if err := processEvents(interruptibleReader{input}, warnEventError); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if traceCSV != "" {
+		if err := closeTrace(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	if err := printResults(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if reportFile != "" {
+		if err := writeReportFile(reportFile, reportFormat); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	for _, target := range emit {
+		if err := writeReportFile(target.path, target.format); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	if concurrencyCSV != "" {
+		if err := writeConcurrencyCSV(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	over := overBudget()
+	slow, limit := testsOverFailOver()
+	if statusFile != "" {
+		if err := writeStatusFile(len(slow) > 0 || len(over) > 0); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	if len(over) > 0 && !brief && !noWarnings {
+		fmt.Fprintf(os.Stderr, "%d packages are over their budget\n", len(over))
+	}
+	if len(slow) > 0 && !brief && !noWarnings {
+		fmt.Fprintf(os.Stderr, "%d tests took longer than %s\n", len(slow), roundDuration(limit))
+	}
+	if len(over) > 0 || len(slow) > 0 {
+		os.Exit(1)
+	}
+	// Input that stalled, such as from a hung go test, and warnings that -no-warnings hid must not pass silently
+	if stdinTimedOut || suppressedWarnings > 0 || (propagateStatus && suiteFailed()) {
+		os.Exit(1)
+	}
+}
+
+// suiteFailed reports whether the last run of any test, or any package, failed. Filters do not apply, so that the
+// exit status mirrors the one of go test.
+func suiteFailed() bool {
+	for _, test := range allTests {
+		if failed(test) {
+			return true
+		}
+	}
+	for _, pkg := range packages {
+		if pkg.Status == "fail" {
+			return true
+		}
+	}
+	return false
+}
+
+// partialReason returns why only part of the input was analyzed, such as with -max-events, or "" if all of it was.
+func partialReason() string {
+	switch {
+	case stoppedAtMaxEvents:
+		return fmt.Sprintf("only the first %d events were analyzed", maxEvents)
+	case stdinTimedOut:
+		return fmt.Sprintf("no input arrived for %s, so the rest of the input was not read", stdinTimeout)
+	}
+	return ""
+}
+
+// errNoTestEvents means the input did not contain any events, usually because go test was run without -json.
+var errNoTestEvents = errors.New("no test events found — did you use go test -json?")
+
+// printResults prints the results of the processed events, or returns errNoTestEvents if there were none.
+func printResults() error {
+	if (packageOnly && len(packages) == 0) || (!packageOnly && testEvents == 0) {
+		return errNoTestEvents
+	}
+
+	if packageOnly {
+		printPackages()
+		printDiagnostics()
+		return nil
+	}
+
+	if stoppedAtMaxEvents {
+		warnf("Partial analysis of only the first %d events", maxEvents)
+	}
+	if !allowIncomplete {
+		for _, runningTest := range runningTests {
+			warnf("Test %s is still running", runningTest.Name)
+		}
+	}
+
+	if brief {
+		printBrief()
+		return nil
+	}
+	printReport()
+	return nil
+}
+
+// processEvents reads go test -json events from input until EOF. An event that does not fit the current test state
+// is passed to onError, which can either return nil to skip the event or return an error to stop processing. Errors
+// reading the input are returned. Besides one event per line, input can be a JSON array of events.
+func processEvents(input io.Reader, onError func(error) error) error {
+	reader := bufio.NewReader(input)
+	if isJSONArray(reader) {
+		return processEventArray(reader, onError)
+	}
 
 	for {
-		exitLoop := false
 		line, err := reader.ReadBytes('\n')
-		switch {
-		case err == io.EOF:
-			exitLoop = true
-		case err != nil:
-			panic(err)
+		if err != nil && err != io.EOF {
+			return err
 		}
-		if exitLoop {
-			break
+		// The last line comes with EOF, and is only empty if the input ends with a newline
+		if len(line) > 0 {
+			if err := processLine(line, onError); err != nil || stoppedAtMaxEvents {
+				return err
+			}
 		}
-		var event Event
-		err = json.Unmarshal(line, &event)
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// processLine processes a line of input that is an event, unless -max-events were processed already, or keeps a line
+// that is not an event to be printed with the results.
+func processLine(line []byte, onError func(error) error) error {
+	var event Event
+	if err := json.Unmarshal(line, &event); err != nil {
+		// Lines that are not events, such as data race reports when stderr is redirected into the input, are kept to
+		// be printed with the results.
+		if text := strings.TrimRight(string(line), "\r\n"); text != "" && !redact {
+			diagnostics = append(diagnostics, text)
+		}
+		return nil
+	}
+	if reachedMaxEvents() {
+		return nil
+	}
+	return processEvent(event, onError)
+}
+
+// isJSONArray skips leading whitespace in the input and reports whether it starts with a JSON array.
+func isJSONArray(reader *bufio.Reader) bool {
+	for {
+		next, err := reader.Peek(1)
 		if err != nil {
-			panic(err)
-		}
-		// Ignore events without a test -- ignore package events
-		if event.Test == "" {
-			continue
-		}
-		switch event.Action {
-		case "run":
-			handleRun(event)
-		case "pause":
-			handlePause(event)
-		case "cont":
-			handleCont(event)
-		case "pass", "skip":
-			handleStop(event)
-		case "output", "start":
-			continue
+			return false
+		}
+		switch next[0] {
+		case ' ', '\t', '\r', '\n':
+			_, _ = reader.ReadByte()
+		case '[':
+			return true
 		default:
-			panic("Unknown action: " + event.Action)
+			return false
 		}
 	}
+}
 
-	for _, runningTest := range runningTests {
-		fmt.Printf("WARNING: Test %s is still running\n", runningTest.Name)
+// processEventArray processes the events of input that is a JSON array of events, as some tools save them, one
+// element at a time.
+func processEventArray(reader io.Reader, onError func(error) error) error {
+	decoder := json.NewDecoder(reader)
+	// Skip the opening bracket
+	if _, err := decoder.Token(); err != nil {
+		return err
+	}
+	for decoder.More() {
+		var event Event
+		if err := decoder.Decode(&event); err != nil {
+			return fmt.Errorf("reading JSON array of events: %w", err)
+		}
+		if reachedMaxEvents() {
+			break
+		}
+		if err := processEvent(event, onError); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reachedMaxEvents reports whether -max-events events were already processed, which means that there are more events
+// than that and the analysis is partial.
+func reachedMaxEvents() bool {
+	if maxEvents > 0 && processedEvents >= maxEvents {
+		stoppedAtMaxEvents = true
+	}
+	return stoppedAtMaxEvents
+}
+
+// processEvent handles a single event, passing an event that does not fit the current test state to onError.
+func processEvent(event Event, onError func(error) error) error {
+	processedEvents++
+	if redact {
+		event = redactEvent(event)
+	}
+	err := handleEvent(event)
+	if traceWriter != nil {
+		traceEvent(event)
+	}
+	if err != nil {
+		return onError(err)
 	}
+	return nil
+}
 
-	// Print the results
-	keys := make([]string, 0, len(allTests))
-	for k := range allTests {
-		keys = append(keys, k)
+// warnf prints a warning to stderr, unless -brief asks for nothing but the summary line or -no-warnings for clean
+// output.
+func warnf(format string, args ...any) {
+	if noWarnings {
+		suppressedWarnings++
+		return
+	}
+	if brief {
+		return
 	}
-	sort.Slice(keys, func(i, j int) bool {
-		return allTests[keys[i]].AdjustedExecutionTime > allTests[keys[j]].AdjustedExecutionTime
-	})
+	fmt.Fprintf(os.Stderr, "WARNING: "+format+"\n", args...)
+}
 
-	results := resultsToList
-	if len(keys) < results {
-		results = len(keys)
+// warnEventError prints an event error as a warning so that processing continues, unless -orphan-pause error asks to
+// stop at an orphan pause.
+func warnEventError(err error) error {
+	if errors.Is(err, errOrphanPause) {
+		return err
+	}
+	warnf("%s", err)
+	return nil
+}
+
+// handleEvent updates the test state with a single event.
+func handleEvent(event Event) error {
+	if firstEventTime.IsZero() {
+		firstEventTime = event.Time
+	}
+	if event.Time.After(lastEventTime) {
+		lastEventTime = event.Time
+	}
+	if timelineInterval > 0 {
+		sampleTimeline(event.Time)
+	}
+	// Package events are tracked separately from test events
+	if event.Test == "" {
+		return handlePackageEvent(event)
 	}
-	for i := 0; i < results; i++ {
-		test := allTests[keys[i]]
-		adjustedRounded := test.AdjustedExecutionTime.Round(time.Millisecond)
-		totalRounded := test.TotalExecutionTime.Round(time.Millisecond)
-		if adjustedRounded != totalRounded {
-			fmt.Printf("%s %s: %s (total: %s parallel: %d)\n", test.Package, test.Name, adjustedRounded, totalRounded,
-				totalRounded/adjustedRounded)
-		} else {
-			fmt.Printf("%s %s: %s\n", test.Package, test.Name, adjustedRounded)
+	testEvents++
+	if packageOnly {
+		return nil
+	}
+	if _, known := allTests[event.Test]; !known && maxTests > 0 && len(allTests) >= maxTests {
+		if droppedTests == 0 {
+			warnf("More than %d tests, leaving out %s and any further new tests", maxTests, event.Test)
 		}
+		droppedTests++
+		return nil
+	}
+	if pauseWindowTest != "" {
+		recordPauseWindow(event)
+	}
+	var err error
+	switch event.Action {
+	case "run":
+		err = handleRun(event)
+	case "pause":
+		err = handlePause(event)
+	case "cont":
+		handleCont(event)
+	case "pass", "fail", "skip":
+		err = handleStop(event)
+	case "output":
+		handleOutput(event)
+	case "start":
+		return nil
+	default:
+		return fmt.Errorf("unknown action %q for test %s", event.Action, event.Test)
 	}
+	recordPeakConcurrency(event.Time)
+	recordIdleTime(event.Time)
+	if concurrencyCSV != "" {
+		recordConcurrency(event.Time)
+	}
+	return err
+}
 
+// recordIdleTime adds up the gaps where no test was active between tests, such as between serial tests of a package.
+func recordIdleTime(now time.Time) {
+	switch {
+	case activeTests == 0 && idleSince.IsZero() && peakConcurrency > 0:
+		idleSince = now
+	case activeTests > 0 && !idleSince.IsZero():
+		idleTime += now.Sub(idleSince)
+		idleSince = time.Time{}
+	}
 }
 
-func handleRun(event Event) {
+// recordPeakConcurrency remembers which tests were running when the number of active tests reached a new maximum.
+func recordPeakConcurrency(now time.Time) {
+	if activeTests <= peakConcurrency {
+		return
+	}
+	peakConcurrency = activeTests
+	peakTime = now
+	peakTests = peakTests[:0]
+	for _, runningTest := range runningTests {
+		if !runningTest.AssumedStopped {
+			peakTests = append(peakTests, runningTest)
+		}
+	}
+}
+
+// resetState discards everything processed so far, such as when a followed log file is truncated.
+func resetState() {
+	allTests = make(map[string]*RunningTest, 1000)
+	runningTests = make(map[string]*RunningTest, 10)
+	activeTests = 0
+	peakConcurrency = 0
+	peakTime = time.Time{}
+	peakTests = nil
+	idleTime = 0
+	idleSince = time.Time{}
+	testEvents = 0
+	diagnostics = nil
+	packages = make(map[string]*PackageResult, 100)
+	timeline = nil
+	coverageEnabled = false
+	firstEventTime = time.Time{}
+	lastEventTime = time.Time{}
+	nextSampleTime = time.Time{}
+	pausedSince = time.Time{}
+	pauseWindowTime = 0
+	pauseWindowTests = make(map[*RunningTest]time.Duration)
+	concurrency = nil
+	processedEvents = 0
+	stoppedAtMaxEvents = false
+	droppedTests = 0
+	framedOutput = false
+}
+
+func handlePackageEvent(event Event) error {
+	if event.Package == "" {
+		return nil
+	}
+	pkg, ok := packages[event.Package]
+	if !ok {
+		pkg = &PackageResult{
+			Name:      event.Package,
+			StartTime: event.Time,
+		}
+		packages[event.Package] = pkg
+	}
+	switch event.Action {
+	case "start":
+		pkg.StartTime = event.Time
+	case "output":
+		if coverageOutputRegexp.MatchString(event.Output) {
+			coverageEnabled = true
+		}
+	case "pass", "fail", "skip":
+		pkg.Status = event.Action
+		pkg.Elapsed = time.Duration(event.Elapsed * float64(time.Second))
+		if pkg.Elapsed == 0 {
+			pkg.Elapsed = event.Time.Sub(pkg.StartTime)
+		}
+		if pkg.TimedOut {
+			return stopTimedOutTests(event)
+		}
+	}
+	return nil
+}
+
+// stopTimedOutTests stops the tests of a package that were killed by the test timeout. go test does not report a
+// result for them, so they end when the package does.
+func stopTimedOutTests(event Event) error {
+	for {
+		var remaining *RunningTest
+		for _, runningTest := range runningTests {
+			if runningTest.Package == event.Package {
+				remaining = runningTest
+				break
+			}
+		}
+		if remaining == nil {
+			return nil
+		}
+		remaining.Status = statusTimeout
+		err := handleStop(Event{Time: event.Time, Action: "fail", Test: remaining.Name, Package: event.Package})
+		// handleStop ignores tests it considers stopped already, which must not keep this loop going
+		removeRunning(remaining.Name)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// sampleTimeline records the longest-running active test at every sample point up to now.
+func sampleTimeline(now time.Time) {
+	if nextSampleTime.IsZero() {
+		nextSampleTime = now.Add(timelineInterval)
+		return
+	}
+	for !nextSampleTime.After(now) {
+		var longest *RunningTest
+		for _, runningTest := range runningTests {
+			if runningTest.AssumedStopped {
+				continue
+			}
+			if longest == nil || runningTest.StartTime.Before(longest.StartTime) ||
+				(runningTest.StartTime.Equal(longest.StartTime) && runningTest.Name < longest.Name) {
+				longest = runningTest
+			}
+		}
+		if longest != nil {
+			timeline = append(timeline, TimelineSample{
+				Time:    nextSampleTime,
+				Test:    longest,
+				Running: nextSampleTime.Sub(longest.StartTime),
+			})
+		}
+		nextSampleTime = nextSampleTime.Add(timelineInterval)
+	}
+}
+
+func handleRun(event Event) error {
+	previous, rerun := allTests[event.Test]
 	allTests[event.Test] = &RunningTest{
 		Name:          event.Test,
 		Package:       event.Package,
+		StartTime:     event.Time,
 		LastTimestamp: event.Time,
 	}
+	if rerun && previous.Package == event.Package && !previous.EndTime.IsZero() {
+		// A test that runs again, such as a retry by gotestsum --rerun-fails or with -count, keeps its earlier attempts
+		allTests[event.Test].Attempts = append(previous.Attempts, previous)
+		previous.Attempts = nil
+	}
 
-	parent, subtest := isSubTest(event.Test)
+	parent, subtest := findParent(event.Test, event.Package)
 
 	if subtest {
-		_, ok := allTests[parent]
-		if !ok {
-			// Parent for subtest must exist. If it doesn't, we go through all tests and find the parent, which is the longest string that is a prefix of the subtest.
-			var names []string
-			for test := range allTests {
-				names = append(names, test)
-			}
-			// Sort names by length in descending order
-			sort.Slice(names, func(i, j int) bool {
-				l1, l2 := len(names[i]), len(names[j])
-				return l1 > l2
-			})
-			for _, name := range names {
-				if strings.HasPrefix(event.Test, name+"/") {
-					parent = name
-					break
-				}
-			}
-			if parent == "" {
-				panic("Parent test not found for subtest: " + event.Test)
-			}
+		// Excluded subtests are left out of their parent's subtests, such as for -overhead
+		if parentTest, ok := allTests[parent]; ok && !excludedSubtest(event.Test) {
+			parentTest.Children = append(parentTest.Children, allTests[event.Test])
 		}
 	}
 
 	if subtest {
-		// Check if the new subtest test is the first child of an existing test.
-		// If it is, stop the execution time of the parent test.
+		// Check if the parent of the new subtest is running. If it is, stop the execution time of the parent test,
+		// since it waits for the subtest. The parent may already have other children, such as parallel subtests.
 		runningParent, ok := runningTests[parent]
 		if ok {
 			allTests[event.Test].Parent = runningParent
-			runningParent.Children = append(runningParent.Children, allTests[event.Test])
-			if len(runningParent.Children) == 1 {
-				updateExecutionTimes(runningParent, event)
-				// Stop the execution time of the parent test -- remove parent from running tests
-				delete(runningTests, runningParent.Name)
-			} else {
-				// Once a child starts up, we should have removed the parent from running tests
-				panic("Running parent test has multiple running children: " + runningParent.Name)
-			}
-			runningTests[event.Test] = allTests[event.Test]
-			return
+			runningParent.RunningChildren = append(runningParent.RunningChildren, allTests[event.Test])
+			updateExecutionTimes(runningParent, event)
+			// Stop the execution time of the parent test -- remove parent from running tests
+			removeRunning(runningParent.Name)
+			setRunning(allTests[event.Test])
+			return nil
 		}
 
-		// Check if the new subtest has currently running siblings. If so, we assume those siblings stop, since subtests run in series by default.
-		for _, runningTest := range runningTests {
-			if stopSibling(event, runningTest, runningTest, parent) {
-				return
+		// Check if the new subtest has currently running siblings. If so, we assume those siblings stop, since subtests
+		// run in series by default, unless -no-assume-stopped asks to wait for their results.
+		if !noAssumeStopped {
+			for _, runningTest := range runningTests {
+				if stopSibling(event, runningTest, runningTest, parent) {
+					return nil
+				}
 			}
 		}
 
-		// Otherwise, this is probably a sibling of a parallel test which is currently paused.
+		// Otherwise, this is probably a sibling of a parallel test which is currently paused. It still belongs to the
+		// parent, which must wait for all of its running children before it continues.
+		if parentTest, ok := allTests[parent]; ok {
+			allTests[event.Test].Parent = parentTest
+			parentTest.RunningChildren = append(parentTest.RunningChildren, allTests[event.Test])
+		}
 	}
 
 	// Update running test durations and add the new test to the list of running tests
 	updateRunningTests(event)
-	runningTests[event.Test] = allTests[event.Test]
+	setRunning(allTests[event.Test])
+	return nil
 }
 
 func stopSibling(event Event, runningTest *RunningTest, potentialSibling *RunningTest, parent string) bool {
@@ -184,10 +1164,10 @@ func stopSibling(event Event, runningTest *RunningTest, potentialSibling *Runnin
 
 		updateExecutionTimes(runningTest, event)
 		// This means that the test is actually finished, but its result had not been reported yet
-		runningTest.AssumedStopped = true
+		setAssumedStopped(runningTest, true)
 		allTests[event.Test].Parent = potentialSibling.Parent
-		potentialSibling.Parent.Children = append(potentialSibling.Parent.Children, allTests[event.Test])
-		runningTests[event.Test] = allTests[event.Test]
+		potentialSibling.Parent.RunningChildren = append(potentialSibling.Parent.RunningChildren, allTests[event.Test])
+		setRunning(allTests[event.Test])
 		// One test swapped for another -- no need to update running times for all tests
 		return true
 	}
@@ -198,24 +1178,64 @@ func stopSibling(event Event, runningTest *RunningTest, potentialSibling *Runnin
 	return false
 }
 
-func handlePause(event Event) {
+// errOrphanPause is the pause of a test that is not running, which stops processing with -orphan-pause error.
+var errOrphanPause = errors.New("paused test not found in running tests")
+
+func handlePause(event Event) error {
 	pausedTest, ok := runningTests[event.Test]
 	if !ok {
-		fmt.Printf("WARNING: Paused test not found in running tests: %s\n", event.Test)
-		return
+		switch orphanPause {
+		case orphanPauseWarn:
+			warnf("Paused test not found in running tests: %s", event.Test)
+		case orphanPauseError:
+			return fmt.Errorf("%w: %s", errOrphanPause, event.Test)
+		}
+		return nil
 	}
 
 	// If test was paused, we assume it was paused due to t.Parallel call
 	pausedTest.Parallel = true
-	pausedTest.AssumedStopped = false
+	setAssumedStopped(pausedTest, false)
 	updateRunningTests(event)
-	delete(runningTests, event.Test)
+	removeRunning(event.Test)
+	if pausedTest.Parent != nil {
+		// t.Run returns once the subtest calls t.Parallel, so the parent test continues, like after a serial subtest
+		resumeParent(pausedTest.Parent, event.Time)
+	}
+	return nil
+}
+
+// resumeParent restarts the execution time of a parent test once none of its subtests are executing, unless the
+// parent test function has returned and it is only waiting for its remaining parallel subtests.
+func resumeParent(parent *RunningTest, now time.Time) {
+	if _, running := runningTests[parent.Name]; running || parent.Status != "" {
+		return
+	}
+	if allTests[parent.Name] != parent {
+		// A test of the same name ran again since, and only that one can run
+		return
+	}
+	if parent.WaitingForSubtests && len(parent.RunningChildren) > 0 {
+		return
+	}
+	for _, child := range parent.RunningChildren {
+		if runningTests[child.Name] == child && !child.AssumedStopped {
+			return
+		}
+	}
+	parent.LastTimestamp = now
+	setRunning(parent)
 }
 
 func handleCont(event Event) {
 	test, ok := allTests[event.Test]
 	if !ok {
-		fmt.Printf("WARNING: Continued test not found in tests: %s\n", event.Test)
+		warnf("Continued test not found in tests: %s", event.Test)
+		return
+	}
+	if !test.EndTime.IsZero() {
+		// Merged or buffered logs can have events after the test stopped, which must not make it run again
+		warnf("Ignoring cont of test that already stopped: %s", event.Test)
 		return
 	}
 
@@ -224,96 +1244,190 @@ func handleCont(event Event) {
 		updateExecutionTimes(runningTest, event)
 	}
 
+	if test.Parent != nil {
+		// Parallel subtests only continue after the parent test function returns, so from now on the parent just
+		// waits for its subtests
+		test.Parent.WaitingForSubtests = true
+		removeRunning(test.Parent.Name)
+	}
+
 	test.LastTimestamp = event.Time
-	test.AssumedStopped = false
-	runningTests[event.Test] = test
+	setAssumedStopped(test, false)
+	setRunning(test)
 }
 
-func updateRunningTests(event Event) {
-	var count uint64
-	for _, runningTest := range runningTests {
-		if !runningTest.AssumedStopped {
-			count++
-		}
+// setRunning adds test to the running tests, keeping the active test count up to date.
+func setRunning(test *RunningTest) {
+	if previous, ok := runningTests[test.Name]; ok && !previous.AssumedStopped {
+		activeTests--
+	}
+	runningTests[test.Name] = test
+	if !test.AssumedStopped {
+		activeTests++
+	}
+}
+
+// removeRunning removes a test from the running tests, keeping the active test count up to date.
+func removeRunning(name string) {
+	test, ok := runningTests[name]
+	if !ok {
+		return
+	}
+	if !test.AssumedStopped {
+		activeTests--
+	}
+	delete(runningTests, name)
+}
+
+// setAssumedStopped changes whether a test is assumed stopped, keeping the active test count up to date.
+func setAssumedStopped(test *RunningTest, stopped bool) {
+	if test.AssumedStopped == stopped {
+		return
 	}
+	test.AssumedStopped = stopped
+	if runningTests[test.Name] != test {
+		return
+	}
+	if stopped {
+		activeTests--
+	} else {
+		activeTests++
+	}
+}
+
+func updateRunningTests(event Event) {
 	for _, runningTest := range runningTests {
-		updateExecutionTimesWithCount(runningTest, event, count)
+		updateExecutionTimesWithCount(runningTest, event, activeTests)
 	}
 }
 
 func updateExecutionTimes(runningTest *RunningTest, event Event) {
-	if runningTest.AssumedStopped {
+	updateExecutionTimesWithCount(runningTest, event, activeTests)
+}
+
+func updateExecutionTimesWithCount(runningTest *RunningTest, event Event, count uint64) {
+	// Merged logs can have events out of order, which must not take time away from the tests
+	if runningTest.AssumedStopped || event.Time.Before(runningTest.LastTimestamp) {
 		return
 	}
-	var count uint64
-	for _, test := range runningTests {
-		if !test.AssumedStopped {
-			count++
-		}
+	elapsed := event.Time.Sub(runningTest.LastTimestamp)
+	runningTest.AdjustedExecutionTime = addDuration(runningTest.AdjustedExecutionTime, elapsed/time.Duration(count))
+	runningTest.TotalExecutionTime = addDuration(runningTest.TotalExecutionTime, elapsed)
+	runningTest.ActiveSeconds += elapsed.Seconds() * float64(count)
+	if count > 1 {
+		runningTest.OverlappedTime = addDuration(runningTest.OverlappedTime, elapsed)
 	}
-	runningTest.AdjustedExecutionTime += event.Time.Sub(runningTest.LastTimestamp) / time.Duration(count)
-	runningTest.TotalExecutionTime += event.Time.Sub(runningTest.LastTimestamp)
 	runningTest.LastTimestamp = event.Time
 }
 
-func updateExecutionTimesWithCount(runningTest *RunningTest, event Event, count uint64) {
-	if runningTest.AssumedStopped {
+// framingLine reports whether output is one of the lines go test adds around the output of each test, such as
+// === RUN and --- PASS, which subtests have indented.
+func framingLine(output string) bool {
+	output = strings.TrimLeft(output, " \t")
+	for _, prefix := range []string{"=== RUN", "=== PAUSE", "=== CONT", "=== NAME", "--- PASS", "--- FAIL", "--- SKIP"} {
+		if strings.HasPrefix(output, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// addDuration adds a non-negative duration to total, stopping at the longest duration instead of overflowing, which
+// corrupt timestamps centuries apart would do.
+func addDuration(total, d time.Duration) time.Duration {
+	if total > math.MaxInt64-d {
+		return math.MaxInt64
+	}
+	return total + d
+}
+
+// handleOutput looks for the output of a test panic or timeout, since go test reports no distinct action for them.
+func handleOutput(event Event) {
+	test, ok := allTests[event.Test]
+	if !ok {
 		return
 	}
-	runningTest.AdjustedExecutionTime += event.Time.Sub(runningTest.LastTimestamp) / time.Duration(count)
-	runningTest.TotalExecutionTime += event.Time.Sub(runningTest.LastTimestamp)
-	runningTest.LastTimestamp = event.Time
+	if includeOutput {
+		test.Output = append(test.Output, event.Output)
+	}
+	recordMemory(test, event.Output)
+	if !test.EndTime.IsZero() {
+		// Output after the test stopped cannot change how it ended
+		return
+	}
+	if framingLine(event.Output) {
+		framedOutput = true
+	} else {
+		test.OutputEvents++
+	}
+	switch {
+	case strings.HasPrefix(event.Output, "panic: test timed out"):
+		test.Status = statusTimeout
+		if pkg, ok := packages[event.Package]; ok {
+			pkg.TimedOut = true
+		}
+	case strings.HasPrefix(event.Output, "panic: ") && test.Status == "":
+		test.Status = statusPanic
+	}
 }
 
-func handleStop(event Event) {
+func handleStop(event Event) error {
+	if stopped, ok := allTests[event.Test]; ok {
+		if !stopped.EndTime.IsZero() {
+			warnf("Ignoring %s of test that already stopped: %s", event.Action, event.Test)
+			return nil
+		}
+		stopped.EndTime = event.Time
+		stopped.WallClock = stopped.EndTime.Sub(stopped.StartTime)
+		if stopped.Status == "" {
+			stopped.Status = event.Action
+		}
+		if stream {
+			// Streamed once the execution times are updated below
+			defer streamTest(stopped)
+		}
+	}
+
 	test, ok := runningTests[event.Test]
 	if !ok {
-		fmt.Printf("WARNING: Stopped test not found in running tests: %s\n", event.Test)
-		return
+		warnf("Stopped test not found in running tests: %s", event.Test)
+		return nil
 	}
 
 	if test.Parent != nil {
-		if len(test.Parent.Children) == 0 {
-			panic("Parent test has no children: " + test.Parent.Name)
-		} else if len(test.Parent.Children) == 1 {
-			// If this is the last executing child of parent, restart the execution time of the parent test
-			updateExecutionTimes(test, event)
-			test.Parent.Children = nil
-			runningTests[test.Parent.Name] = test.Parent
-			runningTests[test.Parent.Name].LastTimestamp = event.Time
-		} else {
-			if !test.AssumedStopped {
-				// If there are still other children executing, update the durations of currently running tests
-				updateRunningTests(event)
-			}
-			// Remove the child from parent
-			for i, child := range test.Parent.Children {
-				if child == test {
-					test.Parent.Children = append(test.Parent.Children[:i], test.Parent.Children[i+1:]...)
-					break
-				}
+		if len(test.Parent.RunningChildren) == 0 {
+			removeRunning(event.Test)
+			return fmt.Errorf("parent test has no children: %s", test.Parent.Name)
+		}
+		// Remove the child from parent
+		for i, child := range test.Parent.RunningChildren {
+			if child == test {
+				test.Parent.RunningChildren = append(test.Parent.RunningChildren[:i], test.Parent.RunningChildren[i+1:]...)
+				break
 			}
 		}
-		delete(runningTests, event.Test)
-		return
+		// Update the durations of currently running tests before the parent test may restart
+		updateRunningTests(event)
+		removeRunning(event.Test)
+		resumeParent(test.Parent, event.Time)
+		return nil
 	}
 
 	updateRunningTests(event)
-	delete(runningTests, event.Test)
+	removeRunning(event.Test)
+	return nil
 }
 
-func isSubTest(test string) (string, bool) {
-	match := subTestRegexp.FindStringSubmatch(test)
-	if len(match) == 0 {
-		return "", false
-	}
-
-	var parent string
-	for i, name := range subTestRegexp.SubexpNames() {
-		if i > 0 && i <= len(match) && name == "parent" {
-			parent = match[i]
-			break
+// findParent returns the name of the parent of a test. A test is only a subtest if a test with the name before one of
+// its slashes was run in the same package, since merged or renamed events may have slashes in the names of top-level
+// tests, and a subset of tests run with go test -run may not include the parent. Such subtests are top-level tests of
+// their own. The parent is usually the name before the last slash, but the name of a subtest can have slashes of its
+// own.
+func findParent(test string, pkg string) (string, bool) {
+	for i := strings.LastIndex(test, "/"); i > 0; i = strings.LastIndex(test[:i], "/") {
+		if parent, ok := allTests[test[:i]]; ok && parent.Package == pkg {
+			return test[:i], true
 		}
 	}
-	return parent, true
+	return "", false
 }
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// BenchmarkHandleEvent runs many parallel tests at once through handleEvent, where the number of active tests must
+// not be recounted on every event.
+func BenchmarkHandleEvent(b *testing.B) {
+	const tests = 1000
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var events []Event
+	for i := 0; i < tests; i++ {
+		name := fmt.Sprintf("Test%d", i)
+		events = append(events,
+			Event{Time: start.Add(time.Duration(i) * time.Millisecond), Action: "run", Test: name, Package: "pkg"},
+			Event{Time: start.Add(time.Duration(i) * time.Millisecond), Action: "pause", Test: name, Package: "pkg"},
+		)
+	}
+	for i := 0; i < tests; i++ {
+		name := fmt.Sprintf("Test%d", i)
+		events = append(events, Event{Time: start.Add(time.Second), Action: "cont", Test: name, Package: "pkg"})
+	}
+	for i := 0; i < tests; i++ {
+		name := fmt.Sprintf("Test%d", i)
+		end := start.Add(2*time.Second + time.Duration(i)*time.Millisecond)
+		events = append(events, Event{Time: end, Action: "pass", Test: name, Package: "pkg"})
+	}
+
+	defer func(previous bool) { noWarnings = previous }(noWarnings)
+	noWarnings = true
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resetState()
+		for _, event := range events {
+			if err := handleEvent(event); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestParallelSubtests(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+	tests := []struct {
+		name     string
+		events   []Event
+		adjusted map[string]time.Duration
+	}{
+		{
+			name: "parent runs between subtests",
+			events: []Event{
+				{Time: at(0), Action: "run", Test: "TestParent"},
+				{Time: at(1), Action: "run", Test: "TestParent/A"},
+				{Time: at(1), Action: "pause", Test: "TestParent/A"},
+				{Time: at(2), Action: "run", Test: "TestParent/B"},
+				{Time: at(2), Action: "pause", Test: "TestParent/B"},
+				{Time: at(3), Action: "run", Test: "TestParent/C"},
+				{Time: at(3), Action: "pause", Test: "TestParent/C"},
+				{Time: at(3), Action: "cont", Test: "TestParent/A"},
+				{Time: at(3), Action: "cont", Test: "TestParent/B"},
+				{Time: at(3), Action: "cont", Test: "TestParent/C"},
+				{Time: at(6), Action: "pass", Test: "TestParent/A"},
+				{Time: at(6), Action: "pass", Test: "TestParent/B"},
+				{Time: at(6), Action: "pass", Test: "TestParent/C"},
+				{Time: at(6), Action: "pass", Test: "TestParent"},
+			},
+			adjusted: map[string]time.Duration{
+				"TestParent":   3 * time.Second,
+				"TestParent/A": time.Second,
+				"TestParent/B": time.Second,
+				"TestParent/C": time.Second,
+			},
+		},
+		{
+			name: "subtests end one after another",
+			events: []Event{
+				{Time: at(0), Action: "run", Test: "TestParent"},
+				{Time: at(1), Action: "run", Test: "TestParent/A"},
+				{Time: at(1), Action: "pause", Test: "TestParent/A"},
+				{Time: at(1), Action: "run", Test: "TestParent/B"},
+				{Time: at(1), Action: "pause", Test: "TestParent/B"},
+				{Time: at(1), Action: "run", Test: "TestParent/C"},
+				{Time: at(1), Action: "pause", Test: "TestParent/C"},
+				{Time: at(1), Action: "cont", Test: "TestParent/A"},
+				{Time: at(1), Action: "cont", Test: "TestParent/B"},
+				{Time: at(1), Action: "cont", Test: "TestParent/C"},
+				{Time: at(4), Action: "pass", Test: "TestParent/A"},
+				{Time: at(6), Action: "pass", Test: "TestParent/B"},
+				{Time: at(7), Action: "pass", Test: "TestParent/C"},
+				{Time: at(7), Action: "pass", Test: "TestParent"},
+			},
+			adjusted: map[string]time.Duration{
+				"TestParent":   time.Second,
+				"TestParent/A": time.Second,
+				"TestParent/B": 2 * time.Second,
+				"TestParent/C": 3 * time.Second,
+			},
+		},
+	}
+	defer func(previous bool) { noWarnings = previous }(noWarnings)
+	noWarnings = true
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			for _, event := range tt.events {
+				event.Package = "pkg"
+				if err := handleEvent(event); err != nil {
+					t.Fatalf("handling %s of %s: %v", event.Action, event.Test, err)
+				}
+			}
+			for name, want := range tt.adjusted {
+				test, ok := allTests[name]
+				if !ok {
+					t.Fatalf("test %s not found", name)
+				}
+				if test.AdjustedExecutionTime != want {
+					t.Errorf("adjusted time of %s = %v, want %v", name, test.AdjustedExecutionTime, want)
+				}
+			}
+			if len(runningTests) != 0 {
+				t.Errorf("%d tests still running", len(runningTests))
+			}
+		})
+	}
+}
+
+// FuzzParse feeds arbitrary input through the event loop, which must neither panic nor hang, and must keep the
+// running tests consistent.
+func FuzzParse(f *testing.F) {
+	// A stale parent: TestParent runs again while its first run waits for its subtest, which then ends
+	f.Add([]byte(`{"Time":"2024-01-01T00:00:00Z","Action":"run","Package":"p","Test":"TestParent"}
+{"Time":"2024-01-01T00:00:01Z","Action":"run","Package":"p","Test":"TestParent/A"}
+{"Time":"2024-01-01T00:00:02Z","Action":"run","Package":"p","Test":"TestParent"}
+{"Time":"2024-01-01T00:00:03Z","Action":"pass","Package":"p","Test":"TestParent"}
+{"Time":"2024-01-01T00:00:04Z","Action":"pass","Package":"p","Test":"TestParent/A"}
+{"Time":"2024-01-01T00:00:05Z","Action":"pass","Package":"p"}
+`))
+	// The same with a package that times out, whose remaining tests used to be stopped forever
+	f.Add([]byte(`{"Time":"2024-01-01T00:00:00Z","Action":"start","Package":"p"}
+{"Time":"2024-01-01T00:00:00Z","Action":"run","Package":"p","Test":"TestParent"}
+{"Time":"2024-01-01T00:00:01Z","Action":"run","Package":"p","Test":"TestParent/A"}
+{"Time":"2024-01-01T00:00:02Z","Action":"run","Package":"p","Test":"TestParent"}
+{"Time":"2024-01-01T00:00:03Z","Action":"pass","Package":"p","Test":"TestParent"}
+{"Time":"2024-01-01T00:00:04Z","Action":"output","Package":"p","Test":"TestParent/A","Output":"panic: test timed out\n"}
+{"Time":"2024-01-01T00:00:04Z","Action":"pass","Package":"p","Test":"TestParent/A"}
+{"Time":"2024-01-01T00:00:05Z","Action":"fail","Package":"p"}
+`))
+	// Events out of time order
+	f.Add([]byte(`{"Time":"2024-01-01T00:00:05Z","Action":"run","Package":"p","Test":"TestA"}
+{"Time":"2024-01-01T00:00:01Z","Action":"run","Package":"p","Test":"TestA/B"}
+{"Time":"2024-01-01T00:00:00Z","Action":"pass","Package":"p","Test":"TestA/B"}
+{"Time":"2024-01-01T00:00:03Z","Action":"pass","Package":"p","Test":"TestA"}
+`))
+	// Timestamps centuries apart, whose execution times used to overflow
+	f.Add([]byte(`{"Time":"0001-01-01T00:00:00Z","Action":"run","Package":"p","Test":"TestA"}
+{"Time":"0001-10-01T00:00:00Z","Action":"run","Package":"p","Test":"TestB"}
+{"Time":"1000-10-01T00:00:00Z","Action":"pass","Package":"p","Test":"TestB"}
+`))
+	f.Add([]byte(`[{"Action":"run","Test":"TestA"},{"Action":"bogus","Test":"TestA"},{"Action":"pause","Test":"TestB"}]`))
+	f.Add([]byte("not an event\n{\"Action\":\"cont\",\"Test\":\"TestA\"}"))
+
+	defer func(previous bool) { brief = previous }(brief)
+	brief = true
+	f.Fuzz(func(t *testing.T, input []byte) {
+		resetState()
+		_ = processEvents(bytes.NewReader(input), func(error) error { return nil })
+
+		var active uint64
+		for name, test := range runningTests {
+			if allTests[name] != test {
+				t.Errorf("running test %s is not its latest run", name)
+			}
+			if !test.AssumedStopped {
+				active++
+			}
+		}
+		if active != activeTests {
+			t.Errorf("%d active tests counted as %d", active, activeTests)
+		}
+		for _, test := range allTests {
+			if test.AdjustedExecutionTime < 0 || test.TotalExecutionTime < 0 {
+				t.Errorf("test %s has negative execution time", test.Name)
+			}
+		}
+	})
+}
+
+func TestProcessEventsWithoutTrailingNewline(t *testing.T) {
+	defer func(previous bool) { noWarnings = previous }(noWarnings)
+	noWarnings = true
+	resetState()
+	input := `{"Time":"2024-01-01T00:00:00Z","Action":"run","Package":"p","Test":"TestA"}
+{"Time":"2024-01-01T00:00:01Z","Action":"pass","Package":"p","Test":"TestA"}`
+	if err := processEvents(strings.NewReader(input), warnEventError); err != nil {
+		t.Fatal(err)
+	}
+	test, ok := allTests["TestA"]
+	if !ok {
+		t.Fatal("TestA not found")
+	}
+	if test.EndTime.IsZero() || test.Status != "pass" {
+		t.Errorf("TestA did not stop: end time %v, status %q", test.EndTime, test.Status)
+	}
+	if len(runningTests) != 0 {
+		t.Errorf("%d tests still running", len(runningTests))
+	}
+}
+
+func TestMaxTests(t *testing.T) {
+	defer func(previous bool) { noWarnings = previous }(noWarnings)
+	noWarnings = true
+	defer func(previous int) { maxTests = previous }(maxTests)
+	maxTests = 10
+	resetState()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 100; i++ {
+		name := fmt.Sprintf("Test%d", i)
+		for _, event := range []Event{
+			{Time: start, Action: "run", Test: name},
+			{Time: start, Action: "run", Test: name + "/sub"},
+			{Time: start, Action: "output", Test: name + "/sub", Output: "ok\n"},
+			{Time: start.Add(time.Second), Action: "pass", Test: name + "/sub"},
+			{Time: start.Add(time.Second), Action: "pass", Test: name},
+		} {
+			event.Package = "pkg"
+			if err := handleEvent(event); err != nil {
+				t.Fatalf("handling %s of %s: %v", event.Action, event.Test, err)
+			}
+		}
+	}
+	if len(allTests) != maxTests {
+		t.Errorf("%d tests tracked, want %d", len(allTests), maxTests)
+	}
+	if droppedTests == 0 {
+		t.Error("no tests dropped")
+	}
+	if len(runningTests) != 0 {
+		t.Errorf("%d tests still running", len(runningTests))
+	}
+}
+
+func TestMinSamples(t *testing.T) {
+	defer func(previous bool) { noWarnings = previous }(noWarnings)
+	noWarnings = true
+	defer func(previous int) { minSamples = previous }(minSamples)
+	minSamples = 3
+	resetState()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var events []Event
+	for run, name := range []string{"TestA", "TestB", "TestA", "TestB", "TestA"} {
+		events = append(events,
+			Event{Time: start.Add(time.Duration(run) * time.Second), Action: "run", Test: name},
+			Event{Time: start.Add(time.Duration(run+1) * time.Second), Action: "pass", Test: name},
+		)
+	}
+	for _, event := range events {
+		event.Package = "pkg"
+		if err := handleEvent(event); err != nil {
+			t.Fatalf("handling %s of %s: %v", event.Action, event.Test, err)
+		}
+	}
+	want := map[string]time.Duration{"TestA": 3 * time.Second, "TestB": time.Second}
+	for _, test := range sumAttempts(filteredTests()) {
+		if test.AdjustedExecutionTime != want[test.Name] {
+			t.Errorf("summed time of %s = %v, want %v", test.Name, test.AdjustedExecutionTime, want[test.Name])
+		}
+	}
+	if !enoughSamples(allTests["TestA"]) || enoughSamples(allTests["TestB"]) {
+		t.Errorf("TestA with 3 runs and TestB with 2 runs were not told apart by -min-samples %d", minSamples)
+	}
+}
+
+func TestFollowStopsAtStdinTimeout(t *testing.T) {
+	defer func(previous bool) { noWarnings = previous }(noWarnings)
+	noWarnings = true
+	defer func(previous time.Duration) { stdinTimeout = previous }(stdinTimeout)
+	stdinTimeout = 50 * time.Millisecond
+	defer func(previous bool) { stdinTimedOut = previous }(stdinTimedOut)
+	resetState()
+	path := filepath.Join(t.TempDir(), "result.json")
+	input := `{"Time":"2024-01-01T00:00:00Z","Action":"run","Package":"p","Test":"TestA"}` + "\n"
+	if err := os.WriteFile(path, []byte(input), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var idle int
+	follow := &followReader{path: path, file: file, onIdle: func() { idle++ }, stopped: make(chan struct{})}
+	if err = processEvents(interruptibleReader{follow}, warnEventError); err != nil {
+		t.Fatal(err)
+	}
+	if !stdinTimedOut {
+		t.Error("reading did not time out")
+	}
+	// The follower must not poll, render, or start over once the timeout gave up on the input
+	stoppedAt := idle
+	time.Sleep(3 * followPollInterval)
+	if idle != stoppedAt {
+		t.Errorf("follower kept polling after the timeout: %d idle calls, want %d", idle, stoppedAt)
+	}
+}
+
+func TestSilentTests(t *testing.T) {
+	defer func(previous bool) { noWarnings = previous }(noWarnings)
+	noWarnings = true
+	resetState()
+	// As written by go test -json, with the lines it adds around every test
+	input := `{"Time":"2024-01-01T00:00:00Z","Action":"start","Package":"p"}
+{"Time":"2024-01-01T00:00:00Z","Action":"run","Package":"p","Test":"TestLogs"}
+{"Time":"2024-01-01T00:00:00Z","Action":"output","Package":"p","Test":"TestLogs","Output":"=== RUN   TestLogs\n"}
+{"Time":"2024-01-01T00:00:00Z","Action":"output","Package":"p","Test":"TestLogs","Output":"    a_test.go:8: connected\n"}
+{"Time":"2024-01-01T00:00:01Z","Action":"output","Package":"p","Test":"TestLogs","Output":"--- PASS: TestLogs (1.00s)\n"}
+{"Time":"2024-01-01T00:00:01Z","Action":"pass","Package":"p","Test":"TestLogs","Elapsed":1}
+{"Time":"2024-01-01T00:00:01Z","Action":"run","Package":"p","Test":"TestTable"}
+{"Time":"2024-01-01T00:00:01Z","Action":"output","Package":"p","Test":"TestTable","Output":"=== RUN   TestTable\n"}
+{"Time":"2024-01-01T00:00:01Z","Action":"run","Package":"p","Test":"TestTable/empty"}
+{"Time":"2024-01-01T00:00:01Z","Action":"output","Package":"p","Test":"TestTable/empty","Output":"=== RUN   TestTable/empty\n"}
+{"Time":"2024-01-01T00:00:01Z","Action":"output","Package":"p","Test":"TestTable/empty","Output":"=== PAUSE TestTable/empty\n"}
+{"Time":"2024-01-01T00:00:01Z","Action":"pause","Package":"p","Test":"TestTable/empty"}
+{"Time":"2024-01-01T00:00:01Z","Action":"cont","Package":"p","Test":"TestTable/empty"}
+{"Time":"2024-01-01T00:00:01Z","Action":"output","Package":"p","Test":"TestTable/empty","Output":"=== CONT  TestTable/empty\n"}
+{"Time":"2024-01-01T00:00:02Z","Action":"output","Package":"p","Test":"TestTable/empty","Output":"    --- PASS: TestTable/empty (1.00s)\n"}
+{"Time":"2024-01-01T00:00:02Z","Action":"pass","Package":"p","Test":"TestTable/empty","Elapsed":1}
+{"Time":"2024-01-01T00:00:02Z","Action":"output","Package":"p","Test":"TestTable","Output":"--- PASS: TestTable (1.00s)\n"}
+{"Time":"2024-01-01T00:00:02Z","Action":"pass","Package":"p","Test":"TestTable","Elapsed":1}
+{"Time":"2024-01-01T00:00:02Z","Action":"output","Package":"p","Output":"PASS\n"}
+{"Time":"2024-01-01T00:00:02Z","Action":"pass","Package":"p","Elapsed":2}
+`
+	if err := processEvents(strings.NewReader(input), warnEventError); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"TestLogs": 1, "TestTable": 0, "TestTable/empty": 0}
+	for name, events := range want {
+		test, ok := allTests[name]
+		if !ok {
+			t.Fatalf("test %s not found", name)
+		}
+		if test.OutputEvents != events {
+			t.Errorf("%s has %d output events, want %d", name, test.OutputEvents, events)
+		}
+	}
+	if !framedOutput {
+		t.Error("the lines go test adds were not noticed")
+	}
+}
+
+func TestNonZeroDuration(t *testing.T) {
+	defer func(previous string) { displayUnit = previous }(displayUnit)
+	tests := []*RunningTest{
+		{Name: "TestInstant"},
+		{Name: "TestMicroseconds", AdjustedExecutionTime: 300 * time.Microsecond},
+		{Name: "TestMilliseconds", AdjustedExecutionTime: 200 * time.Millisecond},
+	}
+	for _, tt := range []struct {
+		unit string
+		want []string
+	}{
+		{unit: "s", want: []string{"TestMilliseconds"}},
+		{unit: "ms", want: []string{"TestMilliseconds"}},
+		{unit: "us", want: []string{"TestMicroseconds", "TestMilliseconds"}},
+		{unit: unitAuto, want: []string{"TestMicroseconds", "TestMilliseconds"}},
+	} {
+		displayUnit = tt.unit
+		var got []string
+		for _, test := range nonZeroDuration(tests) {
+			got = append(got, test.Name)
+		}
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("with -unit %s, kept %v, want %v", tt.unit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// benchmarkMemoryRegexp matches the result line of a benchmark run with -benchmem, such as
+// "BenchmarkX-8 \t 1000\t 1234 ns/op\t 64 B/op\t 2 allocs/op".
+var benchmarkMemoryRegexp = regexp.MustCompile(`\s([\d.]+) ns/op\s+(\d+) B/op\s+(\d+) allocs/op`)
+
+// recordMemory keeps the time and memory per operation from a benchmark result line in the output of a test.
+func recordMemory(test *RunningTest, output string) {
+	match := benchmarkMemoryRegexp.FindStringSubmatch(output)
+	if match == nil {
+		return
+	}
+	test.NsPerOp, _ = strconv.ParseFloat(match[1], 64)
+	test.BytesPerOp, _ = strconv.ParseInt(match[2], 10, 64)
+	test.AllocsPerOp, _ = strconv.ParseInt(match[3], 10, 64)
+	test.MemoryReported = true
+}
+
+// printMemory lists the benchmarks that reported their memory use, the most bytes per operation first, followed by the
+// most allocations per operation.
+func printMemory() {
+	var tests []*RunningTest
+	for _, test := range filteredTests() {
+		if test.MemoryReported {
+			tests = append(tests, test)
+		}
+	}
+	if len(tests) == 0 {
+		return
+	}
+	sort.Slice(tests, func(i, j int) bool {
+		if tests[i].BytesPerOp != tests[j].BytesPerOp {
+			return tests[i].BytesPerOp > tests[j].BytesPerOp
+		}
+		return tests[i].AllocsPerOp > tests[j].AllocsPerOp
+	})
+
+	if len(tests) > topCount {
+		tests = tests[:topCount]
+	}
+
+	fmt.Println("\nMemory per operation:")
+	for _, test := range tests {
+		fmt.Printf("%s %s: %d B/op, %d allocs/op, %s ns/op\n", displayPackage(test.Package), test.Name, test.BytesPerOp,
+			test.AllocsPerOp, strconv.FormatFloat(test.NsPerOp, 'f', -1, 64))
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// PackageSummary aggregates the results of the tests in one package.
+type PackageSummary struct {
+	Name          string
+	Tests         []*RunningTest
+	Adjusted      time.Duration
+	Total         time.Duration
+	FirstFinished *RunningTest
+	LastFinished  *RunningTest
+	// FirstStart is when the first of its tests started, which begins the wall clock span of the package
+	FirstStart time.Time
+}
+
+// summarizePackages groups the tests by package, slowest package first by summed adjusted execution time. Packages
+// whose summed adjusted execution time is below -min-package-time are left out.
+func summarizePackages() []*PackageSummary {
+	byName := make(map[string]*PackageSummary)
+	for _, test := range filteredTests() {
+		summary, ok := byName[test.Package]
+		if !ok {
+			summary = &PackageSummary{Name: test.Package}
+			byName[test.Package] = summary
+		}
+		summary.Tests = append(summary.Tests, test)
+		summary.Adjusted += test.AdjustedExecutionTime
+		summary.Total += test.TotalExecutionTime
+		if summary.FirstStart.IsZero() || test.StartTime.Before(summary.FirstStart) {
+			summary.FirstStart = test.StartTime
+		}
+		if test.EndTime.IsZero() {
+			continue
+		}
+		if summary.FirstFinished == nil || test.EndTime.Before(summary.FirstFinished.EndTime) {
+			summary.FirstFinished = test
+		}
+		if summary.LastFinished == nil || test.EndTime.After(summary.LastFinished.EndTime) {
+			summary.LastFinished = test
+		}
+	}
+
+	summaries := make([]*PackageSummary, 0, len(byName))
+	for _, summary := range byName {
+		if summary.Adjusted >= minPackageTime {
+			summaries = append(summaries, summary)
+		}
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Adjusted > summaries[j].Adjusted
+	})
+	return summaries
+}
+
+// printPackageSummaries prints the per-package view of -by package, including which test finished first and which
+// finished last. The last one is the straggler holding up the completion of the package. The parallelism of a package
+// is the summed total time of its tests over its wall clock span, so a package with many tests and a parallelism near
+// 1 may benefit from t.Parallel.
+func printPackageSummaries() {
+	for _, summary := range summarizePackages() {
+		fmt.Printf("%s: %s (%d tests)\n", displayPackage(summary.Name), roundDuration(summary.Adjusted), len(summary.Tests))
+		if summary.FirstFinished == nil {
+			continue
+		}
+		start := firstEventTime
+		if pkg, ok := packages[summary.Name]; ok {
+			start = pkg.StartTime
+		}
+		fmt.Printf("\tfirst to finish: %s at +%s\n", summary.FirstFinished.Name,
+			roundDuration(summary.FirstFinished.EndTime.Sub(start)))
+		fmt.Printf("\tstraggler: %s at +%s (%s after the first)\n", summary.LastFinished.Name,
+			roundDuration(summary.LastFinished.EndTime.Sub(start)),
+			roundDuration(summary.LastFinished.EndTime.Sub(summary.FirstFinished.EndTime)))
+		if span := summary.LastFinished.EndTime.Sub(summary.FirstStart); span > 0 {
+			fmt.Printf("\tparallelism: %.1f (%s of tests in %s)\n", float64(summary.Total)/float64(span),
+				roundDuration(summary.Total), roundDuration(span))
+		}
+	}
+}
+
+// printCompactPackages prints the one line per package of -compact, slowest package first.
+func printCompactPackages() {
+	for _, summary := range summarizePackages() {
+		slowest := summary.Tests[0]
+		for _, test := range summary.Tests {
+			if test.AdjustedExecutionTime > slowest.AdjustedExecutionTime {
+				slowest = test
+			}
+		}
+		fmt.Printf("%s: %d tests, %s total, slowest %s %s\n", displayPackage(summary.Name), len(summary.Tests),
+			roundDuration(summary.Adjusted), slowest.Name, roundDuration(slowest.AdjustedExecutionTime))
+	}
+}
+
+// printTestCounts prints how many top-level tests and subtests each package has, the package with the most tests first.
+func printTestCounts() {
+	tests := make(map[string]int)
+	subtests := make(map[string]int)
+	for _, test := range filteredTests() {
+		if test.Parent == nil {
+			tests[test.Package]++
+		} else {
+			subtests[test.Package]++
+		}
+	}
+	names := make([]string, 0, len(tests))
+	for name := range tests {
+		names = append(names, name)
+	}
+	for name := range subtests {
+		if tests[name] == 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if tests[names[i]] != tests[names[j]] {
+			return tests[names[i]] > tests[names[j]]
+		}
+		return subtests[names[i]] > subtests[names[j]]
+	})
+
+	fmt.Println("\nTests per package:")
+	for _, name := range names {
+		fmt.Printf("%s: %d tests, %d subtests\n", displayPackage(name), tests[name], subtests[name])
+	}
+}
+
+// printReconciliation flags packages where the adjusted time of their tests adds up to more or less than the elapsed
+// time go test reported for the package by more than the -reconcile fraction of it, which hints at time attributed to
+// the wrong tests. Time outside of tests, such as building and TestMain, also adds to the elapsed time of a package.
+func printReconciliation() {
+	adjusted := make(map[string]time.Duration)
+	for _, test := range allTests {
+		adjusted[test.Package] += test.AdjustedExecutionTime
+	}
+	var names []string
+	for name, pkg := range packages {
+		if pkg.Elapsed <= 0 {
+			continue
+		}
+		difference := float64(adjusted[name]-pkg.Elapsed) / float64(pkg.Elapsed)
+		if math.Abs(difference) > reconcileThreshold {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	sort.Strings(names)
+
+	fmt.Println("\nPackages whose test times do not add up to their elapsed time:")
+	for _, name := range names {
+		elapsed := packages[name].Elapsed
+		fmt.Printf("%s: tests %s, package %s (%+.0f%%)\n", displayPackage(name), roundDuration(adjusted[name]),
+			roundDuration(elapsed), 100*float64(adjusted[name]-elapsed)/float64(elapsed))
+	}
+}
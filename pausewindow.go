@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// pausedSince is when the -pause-window test last paused, or zero while it is not paused.
+var pausedSince time.Time
+
+// pauseWindowTime is how long the -pause-window test has been paused in total.
+var pauseWindowTime time.Duration
+
+// pauseWindowTests is how long each other test was actively running while the -pause-window test was paused.
+var pauseWindowTests = make(map[*RunningTest]time.Duration)
+
+// recordPauseWindow credits the time since the previous event to the tests that were active while the -pause-window
+// test was paused, and starts or ends its pause on its own pause and cont events. It must be called before the event
+// changes which tests are running.
+func recordPauseWindow(event Event) {
+	if !pausedSince.IsZero() {
+		elapsed := event.Time.Sub(pausedSince)
+		pauseWindowTime += elapsed
+		for _, runningTest := range runningTests {
+			if !runningTest.AssumedStopped {
+				pauseWindowTests[runningTest] += elapsed
+			}
+		}
+		pausedSince = event.Time
+	}
+	if event.Test != pauseWindowTest {
+		return
+	}
+	switch event.Action {
+	case "pause":
+		pausedSince = event.Time
+	case "cont", "pass", "fail", "skip":
+		pausedSince = time.Time{}
+	}
+}
+
+// printPauseWindow lists the tests that were actively running while the -pause-window test was paused, longest first.
+func printPauseWindow() {
+	if pauseWindowTime == 0 {
+		fmt.Printf("\n%s was not paused.\n", pauseWindowTest)
+		return
+	}
+	fmt.Printf("\nTests running while %s was paused for %s:\n", pauseWindowTest, roundDuration(pauseWindowTime))
+	tests := make([]*RunningTest, 0, len(pauseWindowTests))
+	for test := range pauseWindowTests {
+		tests = append(tests, test)
+	}
+	sort.Slice(tests, func(i, j int) bool {
+		return pauseWindowTests[tests[i]] > pauseWindowTests[tests[j]]
+	})
+	for _, test := range tests {
+		fmt.Printf("%s %s: %s\n", displayPackage(test.Package), test.Name, roundDuration(pauseWindowTests[test]))
+	}
+}
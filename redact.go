@@ -0,0 +1,34 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// redactEvent replaces the package and test names of an event with stable hashes for -redact, keeping the levels of
+// subtests.
+func redactEvent(event Event) Event {
+	if event.Package != "" {
+		event.Package = redactName("pkg", event.Package)
+	}
+	if event.Test != "" {
+		event.Test = redactTestName(event.Test)
+	}
+	return event
+}
+
+// redactTestName redacts each level of a test name separately, so that subtests stay under their parent test.
+func redactTestName(name string) string {
+	levels := strings.Split(name, "/")
+	for i, level := range levels {
+		levels[i] = redactName("test", level)
+	}
+	return strings.Join(levels, "/")
+}
+
+// redactName replaces a name with a prefixed hash of it, which is the same for the same name in every run.
+func redactName(prefix, name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return prefix + "-" + hex.EncodeToString(sum[:4])
+}
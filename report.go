@@ -0,0 +1,1171 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// printReport prints the slowest tests, or packages with -by package, followed by any optional sections. JSON, TSV,
+// JUnit, and DOT output have no optional sections so that they stay valid.
+func printReport() {
+	machineReadable := outputFormat == formatJSON || outputFormat == formatTSV || outputFormat == formatJUnit ||
+		outputFormat == formatDot || outputFormat == formatSummaryJSON
+	if reason := partialReason(); reason != "" && (summaryOnly || compact || !machineReadable) {
+		// Printed even with -no-warnings, since the numbers below do not cover the whole run
+		fmt.Printf("Partial results: %s\n\n", reason)
+	}
+	if summaryOnly {
+		printSummary()
+		return
+	}
+	if compact {
+		printCompactPackages()
+		return
+	}
+	if groupBy == groupByPackage && !machineReadable {
+		printPackageSummaries()
+	} else if err := writeTests(os.Stdout, outputFormat, rankedTests()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if machineReadable {
+		return
+	}
+
+	if showTree {
+		printTree()
+	}
+	printFailures()
+	printRetries()
+	if rerunDetection {
+		printInconsistentOutcomes()
+	}
+	if includeOutput {
+		printFailureOutput()
+	}
+	printMemory()
+
+	if showOverhead {
+		printOverhead()
+	}
+
+	if outlierThreshold > 0 {
+		printOutliers()
+	}
+
+	if prefixDelimiter != "" {
+		printPrefixGroups()
+	}
+
+	if pauseWindowTest != "" {
+		printPauseWindow()
+	}
+
+	if pareto {
+		printPareto()
+	}
+
+	if contention {
+		printContention()
+	}
+
+	if ranAlone {
+		printRanAlone()
+	}
+
+	if countByPackage {
+		printTestCounts()
+	}
+
+	if singleSubtests {
+		printSingleSubtests()
+	}
+
+	if deepNesting > 0 {
+		printDeepNesting()
+	}
+
+	if silentTests {
+		printSilentTests()
+	}
+
+	if reconcileThreshold > 0 {
+		printReconciliation()
+	}
+
+	if budgets != nil {
+		printBudgets()
+	}
+
+	printCoverageNote()
+
+	if timelineInterval > 0 {
+		fmt.Printf("\nTimeline (longest-running test every %s):\n", timelineInterval)
+		for _, sample := range timeline {
+			fmt.Printf("+%s %s %s: running %s\n", sample.Time.Sub(firstEventTime), displayPackage(sample.Test.Package),
+				sample.Test.Name, roundDuration(sample.Running))
+		}
+	}
+	printDiagnostics()
+}
+
+// printBrief prints the one-line summary of -brief.
+func printBrief() {
+	tests := filteredTests()
+	var slowest *RunningTest
+	for _, test := range tests {
+		if slowest == nil || test.AdjustedExecutionTime > slowest.AdjustedExecutionTime {
+			slowest = test
+		}
+	}
+	wall := roundDuration(lastEventTime.Sub(firstEventTime))
+	if slowest == nil {
+		fmt.Printf("0 tests, total wall %s\n", wall)
+		return
+	}
+	fmt.Printf("%d tests, slowest %s at %s, total wall %s\n", len(tests), slowest.Name,
+		roundDuration(slowest.AdjustedExecutionTime), wall)
+}
+
+// printSummary prints the aggregate numbers of -summary-only: test counts by outcome, the number of packages, wall
+// time, the time saved by running tests in parallel, the idle time between tests, and the peak concurrency.
+func printSummary() {
+	counts := make(map[string]int)
+	var adjusted, total time.Duration
+	tests := filteredTests()
+	for _, test := range tests {
+		counts[test.Status]++
+		adjusted += test.AdjustedExecutionTime
+		total += test.TotalExecutionTime
+	}
+	fmt.Printf("Tests: %d (%d passed, %d failed, %d skipped)\n", len(tests), counts["pass"],
+		counts["fail"]+counts[statusPanic]+counts[statusTimeout], counts["skip"])
+	seen := make(map[string]bool, len(packages))
+	for name := range packages {
+		seen[name] = true
+	}
+	for _, test := range allTests {
+		seen[test.Package] = true
+	}
+	selected := make(map[string]bool)
+	for _, test := range tests {
+		selected[test.Package] = true
+	}
+	if len(selected) < len(seen) {
+		fmt.Printf("Packages: %d (%d with selected tests)\n", len(seen), len(selected))
+	} else {
+		fmt.Printf("Packages: %d\n", len(seen))
+	}
+	fmt.Printf("Wall time: %s\n", roundDuration(lastEventTime.Sub(firstEventTime)))
+	fmt.Printf("Test time: %s adjusted, %s total, %s saved by parallelism\n", roundDuration(adjusted),
+		roundDuration(total), roundDuration(total-adjusted))
+	fmt.Printf("Idle time between tests: %s\n", roundDuration(idleTime))
+	printPeakConcurrency()
+	if deepest := deepestTest(tests); deepest != nil {
+		fmt.Printf("Deepest nesting: %d levels, %s %s\n", nestingDepth(deepest), displayPackage(deepest.Package),
+			deepest.Name)
+	}
+}
+
+// nestingDepth returns how many parents a test has, which is zero for a top-level test.
+func nestingDepth(test *RunningTest) int {
+	depth := 0
+	for parent := test.Parent; parent != nil; parent = parent.Parent {
+		depth++
+	}
+	return depth
+}
+
+// deepestTest returns the first subtest with the most parents, or nil if there are no subtests.
+func deepestTest(tests []*RunningTest) *RunningTest {
+	var deepest *RunningTest
+	for _, test := range tests {
+		if test.Parent != nil && (deepest == nil || nestingDepth(test) > nestingDepth(deepest)) {
+			deepest = test
+		}
+	}
+	return deepest
+}
+
+// testsOverFailOver returns the tests whose adjusted execution time is over the limit, which is -fail-over or
+// -fail-over-factor times the -fail-over-percentile of all tests, whichever is lower. A percentile of zero, such as of
+// skipped tests, is no limit and leaves only -fail-over.
+func testsOverFailOver() (slow []*RunningTest, limit time.Duration) {
+	tests := filteredTests()
+	limit = failOver
+	if failOverPercentile > 0 && len(tests) > 0 {
+		relative := time.Duration(float64(percentile(tests, failOverPercentile)) * failOverFactor)
+		if relative > 0 && (limit <= 0 || relative < limit) {
+			limit = relative
+		}
+	}
+	if limit <= 0 {
+		return nil, 0
+	}
+	for _, test := range tests {
+		if test.AdjustedExecutionTime > limit {
+			slow = append(slow, test)
+		}
+	}
+	return slow, limit
+}
+
+// percentile returns the adjusted execution time that p percent of the tests are at or below, by the nearest-rank
+// method.
+func percentile(tests []*RunningTest, p float64) time.Duration {
+	times := make([]time.Duration, 0, len(tests))
+	for _, test := range tests {
+		times = append(times, test.AdjustedExecutionTime)
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i] < times[j]
+	})
+	rank := int(math.Ceil(p / 100 * float64(len(times))))
+	return times[min(max(rank, 1), len(times))-1]
+}
+
+// printDiagnostics reprints the input lines that were not events, such as race detector reports from stderr.
+func printDiagnostics() {
+	if len(diagnostics) == 0 {
+		return
+	}
+	fmt.Println("\nDiagnostics:")
+	for _, line := range diagnostics {
+		fmt.Println(line)
+	}
+}
+
+// rankedTests returns the tests to report, in the order of sortTests.
+func rankedTests() []*RunningTest {
+	tests := filteredTests()
+	if weightedByCount {
+		tests = sumAttempts(tests)
+	}
+	if amortize {
+		tests = amortizeParents(tests)
+	}
+	if maxDepth >= 0 {
+		tests = collapseToDepth(tests, maxDepth)
+	}
+	if mergeNumbered {
+		tests = mergeNumberedSubtests(tests)
+	}
+	sortTests(tests)
+	if topPerPackage > 0 {
+		tests = topOfEachPackage(tests, topPerPackage)
+	}
+	return tests
+}
+
+// topOfEachPackage keeps the first n ranked tests of each package, grouped by package in the order of their names.
+func topOfEachPackage(tests []*RunningTest, n int) []*RunningTest {
+	kept := make(map[string]int)
+	var top []*RunningTest
+	for _, test := range tests {
+		if kept[test.Package] < n {
+			kept[test.Package]++
+			top = append(top, test)
+		}
+	}
+	sort.SliceStable(top, func(i, j int) bool {
+		return top[i].Package < top[j].Package
+	})
+	return top
+}
+
+// sortTests sorts tests by the -sort key, slowest or last to finish first, or the other way around with -reverse. With
+// -failures-first, failed tests come before the others regardless of the order.
+func sortTests(tests []*RunningTest) {
+	sort.Slice(tests, func(i, j int) bool {
+		if failuresFirst && failed(tests[i]) != failed(tests[j]) {
+			return failed(tests[i])
+		}
+		if reverse {
+			i, j = j, i
+		}
+		if sortBy == sortByEnd {
+			return tests[i].EndTime.After(tests[j].EndTime)
+		}
+		return tests[i].AdjustedExecutionTime > tests[j].AdjustedExecutionTime
+	})
+}
+
+// printTree prints the selected tests as a tree, from top-level tests down through their subtests, each level
+// indented further and sorted like the main list. Subtests deeper than -max-depth are left out.
+func printTree() {
+	var roots []*RunningTest
+	for _, test := range filteredTests() {
+		if test.Parent == nil || !includeTest(test.Parent) {
+			roots = append(roots, test)
+		}
+	}
+	sortTests(roots)
+
+	fmt.Println("\nTest tree:")
+	for _, root := range roots {
+		printSubtree(root, displayPackage(root.Package)+" "+root.Name, 0, 0)
+	}
+}
+
+// printSubtree prints a test under the given label and then its subtests, one level deeper. Tests with subtests also
+// show their cumulative adjusted time, including that of all their subtests, and subtests show their share of the
+// cumulative adjusted time of their parent.
+func printSubtree(test *RunningTest, label string, level int, parentCumulative time.Duration) {
+	cumulative := cumulativeAdjusted(test)
+	details := "total: " + roundDuration(test.TotalExecutionTime).String()
+	if len(test.Children) > 0 {
+		details += ", cumulative: " + roundDuration(cumulative).String()
+	}
+	if parentCumulative > 0 {
+		details += fmt.Sprintf(", %.0f%% of parent", 100*float64(cumulative)/float64(parentCumulative))
+	}
+	fmt.Printf("%s%s: %s (%s)\n", strings.Repeat("  ", level), label, roundDuration(test.AdjustedExecutionTime), details)
+	if maxDepth >= 0 && testDepth(test.Name) >= maxDepth {
+		return
+	}
+	children := append([]*RunningTest(nil), test.Children...)
+	sortTests(children)
+	for _, child := range children {
+		printSubtree(child, strings.TrimPrefix(child.Name, test.Name+"/"), level+1, cumulative)
+	}
+}
+
+// cumulativeAdjusted returns the adjusted execution time of a test and all of its subtests.
+func cumulativeAdjusted(test *RunningTest) time.Duration {
+	sum := test.AdjustedExecutionTime
+	for _, child := range test.Children {
+		sum += cumulativeAdjusted(child)
+	}
+	return sum
+}
+
+// filteredTests returns the tests selected for reporting.
+func filteredTests() []*RunningTest {
+	tests := make([]*RunningTest, 0, len(allTests))
+	for _, test := range allTests {
+		if includeTest(test) {
+			tests = append(tests, test)
+		}
+	}
+	return tests
+}
+
+// includeTest reports whether a test is selected for reporting by the filter flags.
+func includeTest(test *RunningTest) bool {
+	if selectedTest != "" && test.Name != selectedTest && !strings.HasPrefix(test.Name, selectedTest+"/") {
+		return false
+	}
+	if excludedSubtest(test.Name) {
+		return false
+	}
+	if len(onlyPackages) > 0 && !onlyPackages[test.Package] {
+		return false
+	}
+	if slowPasses > 0 && (test.Status != "pass" || test.AdjustedExecutionTime <= slowPasses) {
+		return false
+	}
+	if len(testNamePatterns) > 0 && !slices.ContainsFunc(testNamePatterns, func(re *regexp.Regexp) bool {
+		return re.MatchString(test.Name)
+	}) {
+		return false
+	}
+	return true
+}
+
+// excludedSubtest reports whether a subtest, or one of the subtests it is nested in, matches -exclude-subtest.
+func excludedSubtest(name string) bool {
+	if len(excludeSubtestPatterns) == 0 {
+		return false
+	}
+	levels := strings.Split(name, "/")
+	for i := 2; i <= len(levels); i++ {
+		subtest := strings.Join(levels[:i], "/")
+		if slices.ContainsFunc(excludeSubtestPatterns, func(re *regexp.Regexp) bool {
+			return re.MatchString(subtest)
+		}) {
+			return true
+		}
+	}
+	return false
+}
+
+// topResults limits the ranked tests to the -top number listed in human-readable output, unless -top-per-package
+// already limited them.
+func topResults(tests []*RunningTest) []*RunningTest {
+	if topPerPackage <= 0 && len(tests) > topCount {
+		return tests[:topCount]
+	}
+	return tests
+}
+
+// nonZeroDuration leaves out the tests whose adjusted execution time rounds to zero, which look broken in
+// human-readable output, unless -show-zero-duration asks to list them. Times are rounded to milliseconds, or to the
+// -unit if it is finer, so that a coarse unit such as seconds does not hide tests that ran for a noticeable time.
+func nonZeroDuration(tests []*RunningTest) []*RunningTest {
+	if showZeroDuration {
+		return tests
+	}
+	precision := time.Millisecond
+	if unit, err := parseUnit(displayUnit); err == nil && unit < precision {
+		precision = unit
+	} else if displayUnit == unitAuto {
+		// Auto does not round durations under a millisecond
+		precision = time.Nanosecond
+	}
+	kept := make([]*RunningTest, 0, len(tests))
+	for _, test := range tests {
+		if test.AdjustedExecutionTime.Round(precision) != 0 {
+			kept = append(kept, test)
+		}
+	}
+	return kept
+}
+
+// writeTests writes the ranked tests to w in the given format. Human-readable formats only list the top results,
+// without tests that took no time unless -show-zero-duration, while JSON, TSV, and JUnit have all tests. DOT has the
+// tree of all selected tests rather than the ranked list.
+func writeTests(w io.Writer, format string, tests []*RunningTest) error {
+	switch format {
+	case formatJSON:
+		return writeJSON(w, tests)
+	case formatDot:
+		return writeDot(w)
+	case formatJUnit:
+		return writeJUnit(w, tests)
+	case formatSummaryJSON:
+		return writeSummaryJSON(w)
+	case formatTSV:
+		return writeTSV(w, tests)
+	case formatMarkdown:
+		writeMarkdown(w, topResults(nonZeroDuration(tests)))
+	default:
+		writeText(w, topResults(nonZeroDuration(tests)))
+	}
+	return nil
+}
+
+// writeReportFile writes the ranked tests to a file in a format, for -report-file and -emit.
+func writeReportFile(path string, format string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = writeTests(file, format, rankedTests()); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// testDepth returns how deeply a test is nested, where top-level tests are at depth 0.
+func testDepth(name string) int {
+	return strings.Count(name, "/")
+}
+
+// collapseToDepth folds subtests nested deeper than depth into their ancestor at that depth, adding their execution
+// times to it and extending it to when they end. The tests are copied so that the collected results stay intact.
+func collapseToDepth(tests []*RunningTest, depth int) []*RunningTest {
+	collapsed := make(map[string]*RunningTest, len(tests))
+	for _, test := range tests {
+		if testDepth(test.Name) <= depth {
+			collapsed[test.Package+" "+test.Name] = &RunningTest{
+				Name:                  test.Name,
+				Package:               test.Package,
+				StartTime:             test.StartTime,
+				EndTime:               test.EndTime,
+				AdjustedExecutionTime: test.AdjustedExecutionTime,
+				TotalExecutionTime:    test.TotalExecutionTime,
+				ActiveSeconds:         test.ActiveSeconds,
+				WallClock:             test.WallClock,
+				Status:                test.Status,
+			}
+		}
+	}
+	for _, test := range tests {
+		if testDepth(test.Name) <= depth {
+			continue
+		}
+		ancestorName := strings.Join(strings.SplitN(test.Name, "/", depth+2)[:depth+1], "/")
+		ancestor, ok := collapsed[test.Package+" "+ancestorName]
+		if !ok {
+			ancestor = &RunningTest{Name: ancestorName, Package: test.Package}
+			collapsed[test.Package+" "+ancestorName] = ancestor
+		}
+		ancestor.AdjustedExecutionTime += test.AdjustedExecutionTime
+		ancestor.TotalExecutionTime += test.TotalExecutionTime
+		ancestor.ActiveSeconds += test.ActiveSeconds
+		extendSpan(ancestor, test)
+	}
+
+	result := make([]*RunningTest, 0, len(collapsed))
+	for _, test := range collapsed {
+		result = append(result, test)
+	}
+	return result
+}
+
+// sumAttempts adds the execution times of the earlier runs of each test, such as with go test -count, to its last run,
+// to rank the tests that are cumulatively expensive. The tests are copied so that the collected results stay intact.
+func sumAttempts(tests []*RunningTest) []*RunningTest {
+	summed := make([]*RunningTest, 0, len(tests))
+	for _, test := range tests {
+		if !enoughSamples(test) {
+			summed = append(summed, test)
+			continue
+		}
+		copied := *test
+		for _, attempt := range test.Attempts {
+			copied.AdjustedExecutionTime += attempt.AdjustedExecutionTime
+			copied.TotalExecutionTime += attempt.TotalExecutionTime
+			copied.ActiveSeconds += attempt.ActiveSeconds
+			copied.WallClock += attempt.WallClock
+		}
+		summed = append(summed, &copied)
+	}
+	return summed
+}
+
+// amortizeParents adds an even share of each parent test's own execution time, such as its setup of fixtures for the
+// subtests, to each of its subtests. The tests are copied so that the collected results stay intact.
+func amortizeParents(tests []*RunningTest) []*RunningTest {
+	amortized := make([]*RunningTest, 0, len(tests))
+	for _, test := range tests {
+		copied := *test
+		if parent := test.Parent; parent != nil && len(parent.Children) > 0 {
+			copied.AdjustedExecutionTime += parent.AdjustedExecutionTime / time.Duration(len(parent.Children))
+			copied.TotalExecutionTime += parent.TotalExecutionTime / time.Duration(len(parent.Children))
+		}
+		amortized = append(amortized, &copied)
+	}
+	return amortized
+}
+
+// numberedSuffixRegexp matches the suffix go test adds to make duplicate subtest names unique.
+var numberedSuffixRegexp = regexp.MustCompile(`(#\d{2,})+$`)
+
+// mergeNumberedSubtests combines subtests whose names only differ by numbered suffixes, like case, case#01, and
+// case#02, into one test with their summed execution times, spanning from the first start to the last end, which failed
+// if any of them failed. Subtests without a name, which go test names #00, #01, and so on, are combined under #00. The
+// tests are copied so that the collected results stay intact.
+func mergeNumberedSubtests(tests []*RunningTest) []*RunningTest {
+	merged := make(map[string]*RunningTest, len(tests))
+	var result []*RunningTest
+	for _, test := range tests {
+		levels := strings.Split(test.Name, "/")
+		for i := 1; i < len(levels); i++ {
+			if levels[i] = numberedSuffixRegexp.ReplaceAllString(levels[i], ""); levels[i] == "" {
+				levels[i] = "#00"
+			}
+		}
+		name := strings.Join(levels, "/")
+		existing, ok := merged[test.Package+" "+name]
+		if !ok {
+			existing = &RunningTest{Name: name, Package: test.Package, Status: test.Status}
+			merged[test.Package+" "+name] = existing
+			result = append(result, existing)
+		}
+		if failed(test) && !failed(existing) {
+			existing.Status = test.Status
+		}
+		extendSpan(existing, test)
+		existing.AdjustedExecutionTime += test.AdjustedExecutionTime
+		existing.TotalExecutionTime += test.TotalExecutionTime
+		existing.ActiveSeconds += test.ActiveSeconds
+		existing.WallClock += test.WallClock
+		existing.Merged++
+	}
+	return result
+}
+
+// extendSpan widens the start and end times of a test that combines others to include those of test.
+func extendSpan(combined *RunningTest, test *RunningTest) {
+	if combined.StartTime.IsZero() || (!test.StartTime.IsZero() && test.StartTime.Before(combined.StartTime)) {
+		combined.StartTime = test.StartTime
+	}
+	if test.EndTime.After(combined.EndTime) {
+		combined.EndTime = test.EndTime
+	}
+}
+
+// displayPackage shortens the name of a package for display by removing the -trim-prefix.
+func displayPackage(name string) string {
+	return strings.TrimPrefix(name, trimPrefix)
+}
+
+// testLabel is the name of a test in the list of results, with the number of tests merged into it, if any.
+func testLabel(test *RunningTest) string {
+	if test.Merged > 1 {
+		return fmt.Sprintf("%s (%d merged)", test.Name, test.Merged)
+	}
+	if weightedByCount && len(test.Attempts) > 0 {
+		return fmt.Sprintf("%s (%d runs)", test.Name, len(test.Attempts)+1)
+	}
+	return test.Name
+}
+
+func writeText(w io.Writer, tests []*RunningTest) {
+	for i := 0; i < len(tests); i++ {
+		test := tests[i]
+		adjustedRounded := roundDuration(test.AdjustedExecutionTime)
+		if identical := identicalDurations(tests[i:]); groupIdentical && identical > 1 {
+			fmt.Fprintf(w, "%d tests: %s each\n", identical, adjustedRounded)
+			i += identical - 1
+			continue
+		}
+		totalRounded := roundDuration(test.TotalExecutionTime)
+		showTotal := adjustedRounded != totalRounded
+		switch totalsMode {
+		case totalsAlways:
+			showTotal = true
+		case totalsNever:
+			showTotal = false
+		}
+		pkg := displayPackage(test.Package)
+		var details []string
+		if showTotal || verboseColumns {
+			details = append(details, "total: "+totalRounded.String())
+		}
+		if showParallelism || (showTotal && !verboseColumns && parallelism(test) > 0) {
+			details = append(details, "parallel: "+formatParallelism(test))
+		}
+		if showAverageActive {
+			details = append(details, "average active: "+formatAverageActive(test))
+		}
+		switch {
+		case verboseColumns:
+			fmt.Fprintf(w, "%s %s: wall clock: %s adjusted: %s %s\n", pkg, testLabel(test),
+				roundDuration(test.WallClock), adjustedRounded, strings.Join(details, " "))
+		case len(details) > 0:
+			fmt.Fprintf(w, "%s %s: %s (%s)\n", pkg, testLabel(test), adjustedRounded, strings.Join(details, " "))
+		default:
+			fmt.Fprintf(w, "%s %s: %s\n", pkg, testLabel(test), adjustedRounded)
+		}
+	}
+}
+
+// identicalDurations returns how many of the tests, from the first, have the same adjusted execution time when
+// rounded for display.
+func identicalDurations(tests []*RunningTest) int {
+	n := 1
+	for n < len(tests) && roundDuration(tests[n].AdjustedExecutionTime) == roundDuration(tests[0].AdjustedExecutionTime) {
+		n++
+	}
+	return n
+}
+
+// roundDuration rounds a duration for display to the -unit. With auto, durations of at least a second are rounded to
+// milliseconds and durations of at least a millisecond to microseconds.
+func roundDuration(d time.Duration) time.Duration {
+	if displayUnit == unitAuto {
+		switch {
+		case d >= time.Second:
+			return d.Round(time.Millisecond)
+		case d >= time.Millisecond:
+			return d.Round(time.Microsecond)
+		}
+		return d
+	}
+	unit, _ := parseUnit(displayUnit)
+	return d.Round(unit)
+}
+
+// parallelism returns the speedup from running alongside other tests, which is the total execution time over the
+// adjusted execution time. It is zero when the adjusted execution time is zero.
+func parallelism(test *RunningTest) float64 {
+	if test.AdjustedExecutionTime <= 0 {
+		return 0
+	}
+	return float64(test.TotalExecutionTime) / float64(test.AdjustedExecutionTime)
+}
+
+// averageActive returns the time-weighted average number of active tests, including the test itself, that the
+// execution time of the test was divided by to get its adjusted execution time. It is zero without execution time.
+func averageActive(test *RunningTest) float64 {
+	if test.TotalExecutionTime <= 0 {
+		return 0
+	}
+	return test.ActiveSeconds / test.TotalExecutionTime.Seconds()
+}
+
+// formatAverageActive formats the average number of active tests during a test, or "-" when there is none.
+func formatAverageActive(test *RunningTest) string {
+	if averageActive(test) == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", averageActive(test))
+}
+
+// formatParallelism formats the parallelism factor of a test, or "-" when there is none.
+func formatParallelism(test *RunningTest) string {
+	if parallelism(test) == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", parallelism(test))
+}
+
+// writeMarkdown writes the tests as a GitHub-flavored Markdown table.
+func writeMarkdown(w io.Writer, tests []*RunningTest) {
+	header := "| Package | Test | Adjusted | Total | Wall clock |"
+	separator := "| --- | --- | --- | --- | --- |"
+	if showParallelism {
+		header += " Parallel |"
+		separator += " --- |"
+	}
+	if showAverageActive {
+		header += " Average active |"
+		separator += " --- |"
+	}
+	fmt.Fprintln(w, header)
+	fmt.Fprintln(w, separator)
+	for _, test := range tests {
+		fmt.Fprintf(w, "| %s | %s | %s | %s | %s |", escapeMarkdown(displayPackage(test.Package)),
+			escapeMarkdown(testLabel(test)), roundDuration(test.AdjustedExecutionTime),
+			roundDuration(test.TotalExecutionTime), roundDuration(test.WallClock))
+		if showParallelism {
+			fmt.Fprintf(w, " %s |", formatParallelism(test))
+		}
+		if showAverageActive {
+			fmt.Fprintf(w, " %s |", formatAverageActive(test))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// escapeMarkdown escapes pipe characters so they do not end a Markdown table cell.
+func escapeMarkdown(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+func printPackages() {
+	names := make([]string, 0, len(packages))
+	for name := range packages {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return packages[names[i]].Elapsed > packages[names[j]].Elapsed
+	})
+	for _, name := range names {
+		pkg := packages[name]
+		status := pkg.Status
+		if status == "" {
+			status = "incomplete"
+		}
+		fmt.Printf("%s: %s %s\n", displayPackage(pkg.Name), status, roundDuration(pkg.Elapsed))
+	}
+}
+
+// printPeakConcurrency prints the most tests that were running at once in the summary, and lists those tests.
+func printPeakConcurrency() {
+	if peakConcurrency == 0 {
+		return
+	}
+	fmt.Printf("Peak concurrency: %d tests at +%s\n", peakConcurrency, roundDuration(peakTime.Sub(firstEventTime)))
+	for _, test := range sortedPeakTests() {
+		fmt.Printf("  %s %s\n", displayPackage(test.Package), test.Name)
+	}
+}
+
+// sortedPeakTests returns the tests that were running when the most tests were running at once, sorted by package and
+// name.
+func sortedPeakTests() []*RunningTest {
+	tests := append([]*RunningTest(nil), peakTests...)
+	sort.Slice(tests, func(i, j int) bool {
+		return tests[i].Package+" "+tests[i].Name < tests[j].Package+" "+tests[j].Name
+	})
+	return tests
+}
+
+// printFailures lists the tests that panicked or timed out and how long they ran before dying.
+func printFailures() {
+	var failures []*RunningTest
+	for _, test := range filteredTests() {
+		if test.Status == statusPanic || test.Status == statusTimeout {
+			failures = append(failures, test)
+		}
+	}
+	if len(failures) == 0 {
+		return
+	}
+	sort.Slice(failures, func(i, j int) bool {
+		return failures[i].StartTime.Before(failures[j].StartTime)
+	})
+
+	fmt.Println("\nPanicked or timed out:")
+	for _, test := range failures {
+		fmt.Printf("%s %s: %s after %s\n", displayPackage(test.Package), test.Name, test.Status,
+			roundDuration(test.WallClock))
+	}
+}
+
+// printPareto lists the slowest tests with the cumulative percentage of the summed adjusted time of all selected tests
+// that they account for, to see how many tests need to get faster to cut the suite time by a given share.
+func printPareto() {
+	tests := filteredTests()
+	sort.Slice(tests, func(i, j int) bool {
+		return tests[i].AdjustedExecutionTime > tests[j].AdjustedExecutionTime
+	})
+	var sum time.Duration
+	for _, test := range tests {
+		sum += test.AdjustedExecutionTime
+	}
+	if sum <= 0 {
+		return
+	}
+
+	fmt.Println("\nCumulative share of adjusted time:")
+	var cumulative time.Duration
+	for _, test := range topResults(tests) {
+		cumulative += test.AdjustedExecutionTime
+		fmt.Printf("%s %s: %s (%.1f%%, cumulative %.1f%%)\n", displayPackage(test.Package), test.Name,
+			roundDuration(test.AdjustedExecutionTime), 100*float64(test.AdjustedExecutionTime)/float64(sum),
+			100*float64(cumulative)/float64(sum))
+	}
+}
+
+// printContention lists the tests with the highest contention score, which is the time-weighted average number of
+// other tests that were active alongside them. Their adjusted time was divided the most, so they may be candidates for
+// running in isolation.
+func printContention() {
+	var contended []*RunningTest
+	for _, test := range filteredTests() {
+		if test.OverlappedTime > 0 {
+			contended = append(contended, test)
+		}
+	}
+	if len(contended) == 0 {
+		return
+	}
+	sort.Slice(contended, func(i, j int) bool {
+		if averageActive(contended[i]) != averageActive(contended[j]) {
+			return averageActive(contended[i]) > averageActive(contended[j])
+		}
+		return contended[i].Package+" "+contended[i].Name < contended[j].Package+" "+contended[j].Name
+	})
+
+	fmt.Println("\nMost contended tests (average number of other active tests):")
+	for _, test := range topResults(contended) {
+		fmt.Printf("%s %s: %.1f others, overlapped %.0f%% of %s\n", displayPackage(test.Package), test.Name,
+			averageActive(test)-1, 100*float64(test.OverlappedTime)/float64(test.TotalExecutionTime),
+			roundDuration(test.TotalExecutionTime))
+	}
+}
+
+// printRanAlone classifies the slowest tests by whether they ever ran as the only active test, with the share of their
+// execution time they had to themselves. The adjusted time of tests that never ran alone is divided throughout, so it
+// depends on what else was running.
+func printRanAlone() {
+	var tests []*RunningTest
+	for _, test := range filteredTests() {
+		if test.TotalExecutionTime > 0 {
+			tests = append(tests, test)
+		}
+	}
+	if len(tests) == 0 {
+		return
+	}
+	sortTests(tests)
+
+	fmt.Println("\nTests by whether they ran alone:")
+	for _, test := range topResults(tests) {
+		alone := test.TotalExecutionTime - test.OverlappedTime
+		if alone <= 0 {
+			fmt.Printf("%s %s: never alone (%s)\n", displayPackage(test.Package), test.Name,
+				roundDuration(test.TotalExecutionTime))
+			continue
+		}
+		fmt.Printf("%s %s: alone %.0f%% of %s\n", displayPackage(test.Package), test.Name,
+			100*float64(alone)/float64(test.TotalExecutionTime), roundDuration(test.TotalExecutionTime))
+	}
+}
+
+// printSingleSubtests lists the tests that have exactly one subtest, where t.Run often adds overhead without grouping
+// anything, as candidates for refactoring.
+func printSingleSubtests() {
+	var parents []*RunningTest
+	for _, test := range filteredTests() {
+		if len(test.Children) == 1 {
+			parents = append(parents, test)
+		}
+	}
+	if len(parents) == 0 {
+		return
+	}
+	sort.Slice(parents, func(i, j int) bool {
+		return parents[i].Package+" "+parents[i].Name < parents[j].Package+" "+parents[j].Name
+	})
+
+	fmt.Println("\nTests with a single subtest:")
+	for _, test := range parents {
+		fmt.Printf("%s %s: only %s\n", displayPackage(test.Package), test.Name, test.Children[0].Name)
+	}
+}
+
+// printDeepNesting lists the subtests nested more than -deep-nesting levels deep, the deepest first.
+func printDeepNesting() {
+	var deep []*RunningTest
+	for _, test := range filteredTests() {
+		if nestingDepth(test) > deepNesting {
+			deep = append(deep, test)
+		}
+	}
+	if len(deep) == 0 {
+		return
+	}
+	sort.Slice(deep, func(i, j int) bool {
+		if nestingDepth(deep[i]) != nestingDepth(deep[j]) {
+			return nestingDepth(deep[i]) > nestingDepth(deep[j])
+		}
+		return deep[i].Package+" "+deep[i].Name < deep[j].Package+" "+deep[j].Name
+	})
+
+	fmt.Printf("\nTests nested more than %d levels deep:\n", deepNesting)
+	for _, test := range deep {
+		fmt.Printf("%s %s: %d levels\n", displayPackage(test.Package), test.Name, nestingDepth(test))
+	}
+}
+
+// printSilentTests lists the tests that passed without any output between their run and their result, besides the
+// === RUN and --- PASS lines go test adds, so they may not have run their body. Input without any output events, such
+// as from a tool that strips them, has nothing to report.
+func printSilentTests() {
+	var silent []*RunningTest
+	anyOutput := framedOutput
+	for _, test := range filteredTests() {
+		anyOutput = anyOutput || test.OutputEvents > 0
+		if test.Status == "pass" && test.OutputEvents == 0 {
+			silent = append(silent, test)
+		}
+	}
+	if !anyOutput || len(silent) == 0 {
+		return
+	}
+	sort.Slice(silent, func(i, j int) bool {
+		return silent[i].Package+" "+silent[i].Name < silent[j].Package+" "+silent[j].Name
+	})
+
+	fmt.Println("\nTests that passed without output:")
+	for _, test := range silent {
+		fmt.Printf("%s %s: %s\n", displayPackage(test.Package), test.Name, roundDuration(test.AdjustedExecutionTime))
+	}
+}
+
+// printRetries lists the tests that ran again after failing, such as with gotestsum --rerun-fails, with the outcome and
+// adjusted time of each attempt. Tests that passed on a retry are flaky.
+func printRetries() {
+	var retried []*RunningTest
+	for _, test := range filteredTests() {
+		if enoughSamples(test) && slices.ContainsFunc(test.Attempts, failed) {
+			retried = append(retried, test)
+		}
+	}
+	if len(retried) == 0 {
+		return
+	}
+	sort.Slice(retried, func(i, j int) bool {
+		return retried[i].Package+" "+retried[i].Name < retried[j].Package+" "+retried[j].Name
+	})
+
+	fmt.Println("\nRetried tests:")
+	for _, test := range retried {
+		attempts := make([]string, 0, len(test.Attempts)+1)
+		for _, attempt := range append(slices.Clone(test.Attempts), test) {
+			attempts = append(attempts, attempt.Status+" "+roundDuration(attempt.AdjustedExecutionTime).String())
+		}
+		outcome := "flaky, passed on attempt " + strconv.Itoa(len(attempts))
+		if test.Status != "pass" {
+			outcome = "failed all " + strconv.Itoa(len(attempts)) + " attempts"
+		}
+		fmt.Printf("%s %s: %s (%s)\n", displayPackage(test.Package), test.Name, outcome, strings.Join(attempts, ", "))
+	}
+}
+
+// printInconsistentOutcomes lists the tests whose runs had more than one distinct outcome, such as a test that failed
+// and passed with go test -count, with how many runs had each outcome.
+func printInconsistentOutcomes() {
+	var inconsistent []*RunningTest
+	for _, test := range filteredTests() {
+		if !enoughSamples(test) {
+			continue
+		}
+		for _, attempt := range test.Attempts {
+			if attempt.Status != test.Status {
+				inconsistent = append(inconsistent, test)
+				break
+			}
+		}
+	}
+	if len(inconsistent) == 0 {
+		return
+	}
+	sort.Slice(inconsistent, func(i, j int) bool {
+		return inconsistent[i].Package+" "+inconsistent[i].Name < inconsistent[j].Package+" "+inconsistent[j].Name
+	})
+
+	fmt.Println("\nInconsistent outcomes:")
+	for _, test := range inconsistent {
+		counts := make(map[string]int)
+		for _, attempt := range append(slices.Clone(test.Attempts), test) {
+			counts[attempt.Status]++
+		}
+		statuses := make([]string, 0, len(counts))
+		for status := range counts {
+			statuses = append(statuses, status)
+		}
+		sort.Strings(statuses)
+		outcomes := make([]string, 0, len(statuses))
+		for _, status := range statuses {
+			outcomes = append(outcomes, status+" "+strconv.Itoa(counts[status]))
+		}
+		fmt.Printf("%s %s: %s\n", displayPackage(test.Package), test.Name, strings.Join(outcomes, ", "))
+	}
+}
+
+// enoughSamples reports whether a test ran at least -min-samples times, counting its earlier attempts, so that its
+// runs are worth comparing.
+func enoughSamples(test *RunningTest) bool {
+	return len(test.Attempts)+1 >= minSamples
+}
+
+// failed reports whether a test failed, panicked, or timed out.
+func failed(test *RunningTest) bool {
+	return test.Status == "fail" || test.Status == statusPanic || test.Status == statusTimeout
+}
+
+// printFailureOutput prints the output of the tests that failed, panicked, or timed out, in the order they started.
+func printFailureOutput() {
+	var failures []*RunningTest
+	for _, test := range filteredTests() {
+		if failed(test) {
+			failures = append(failures, test)
+		}
+	}
+	sort.Slice(failures, func(i, j int) bool {
+		return failures[i].StartTime.Before(failures[j].StartTime)
+	})
+
+	for _, test := range failures {
+		fmt.Printf("\nOutput of %s %s (%s):\n", displayPackage(test.Package), test.Name, test.Status)
+		for _, line := range test.Output {
+			fmt.Print(line)
+		}
+	}
+}
+
+// printPrefixGroups sums the adjusted execution time of tests grouped by name prefix, such as a feature area in
+// TestAuth_Login and TestAuth_Logout. Subtests are grouped with their top-level test.
+func printPrefixGroups() {
+	times := make(map[string]time.Duration)
+	counts := make(map[string]int)
+	for _, test := range filteredTests() {
+		topLevel, _, _ := strings.Cut(test.Name, "/")
+		prefix, _, _ := strings.Cut(topLevel, prefixDelimiter)
+		times[prefix] += test.AdjustedExecutionTime
+		counts[prefix]++
+	}
+	prefixes := make([]string, 0, len(times))
+	for prefix := range times {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		return times[prefixes[i]] > times[prefixes[j]]
+	})
+
+	fmt.Printf("\nGrouped by prefix before %q:\n", prefixDelimiter)
+	for _, prefix := range prefixes {
+		fmt.Printf("%s: %s (%d tests)\n", prefix, roundDuration(times[prefix]), counts[prefix])
+	}
+}
+
+// printOutliers lists tests whose adjusted execution time is more than outlierThreshold standard deviations above the
+// mean of their package, which points at a single regressed test among many similar ones.
+func printOutliers() {
+	byPackage := make(map[string][]*RunningTest)
+	for _, test := range filteredTests() {
+		byPackage[test.Package] = append(byPackage[test.Package], test)
+	}
+
+	type outlier struct {
+		test   *RunningTest
+		zScore float64
+	}
+	var outliers []outlier
+	for _, tests := range byPackage {
+		if len(tests) < 2 {
+			continue
+		}
+		var mean float64
+		for _, test := range tests {
+			mean += float64(test.AdjustedExecutionTime)
+		}
+		mean /= float64(len(tests))
+		var variance float64
+		for _, test := range tests {
+			variance += math.Pow(float64(test.AdjustedExecutionTime)-mean, 2)
+		}
+		stdDev := math.Sqrt(variance / float64(len(tests)))
+		if stdDev == 0 {
+			continue
+		}
+		for _, test := range tests {
+			zScore := (float64(test.AdjustedExecutionTime) - mean) / stdDev
+			if zScore > outlierThreshold {
+				outliers = append(outliers, outlier{test: test, zScore: zScore})
+			}
+		}
+	}
+	sort.Slice(outliers, func(i, j int) bool {
+		return outliers[i].zScore > outliers[j].zScore
+	})
+
+	fmt.Printf("\nOutliers (more than %g standard deviations above their package mean):\n", outlierThreshold)
+	for _, o := range outliers {
+		fmt.Printf("%s %s: %s (z-score: %.2f)\n", displayPackage(o.test.Package), o.test.Name,
+			roundDuration(o.test.AdjustedExecutionTime), o.zScore)
+	}
+}
+
+// subtestTime returns the total execution time of all descendants of test.
+func subtestTime(test *RunningTest) time.Duration {
+	var sum time.Duration
+	for _, child := range test.Children {
+		sum += child.TotalExecutionTime + subtestTime(child)
+	}
+	return sum
+}
+
+// printOverhead reports the time each parent test spent outside of its subtests, which is effectively setup and
+// teardown. A parent's execution time is paused while its subtests run, so its total is already that overhead.
+func printOverhead() {
+	var parents []*RunningTest
+	for _, test := range filteredTests() {
+		if len(test.Children) > 0 {
+			parents = append(parents, test)
+		}
+	}
+	sort.Slice(parents, func(i, j int) bool {
+		return parents[i].TotalExecutionTime > parents[j].TotalExecutionTime
+	})
+	if len(parents) > topCount {
+		parents = parents[:topCount]
+	}
+
+	fmt.Println("\nSetup/teardown overhead:")
+	for _, test := range parents {
+		overhead := roundDuration(test.TotalExecutionTime)
+		subtests := roundDuration(subtestTime(test))
+		if subtests > 0 {
+			fmt.Printf("%s %s: %s (subtests: %s ratio: %.2f)\n", displayPackage(test.Package), test.Name, overhead, subtests,
+				float64(overhead)/float64(subtests))
+		} else {
+			fmt.Printf("%s %s: %s (subtests: %s)\n", displayPackage(test.Package), test.Name, overhead, subtests)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+)
+
+// serve listens for connections that each stream go test -json output, such as from
+// go test -json ./... | nc localhost 9000, and prints the results of each stream when its connection closes.
+func serve(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addAnalysisFlags(fs)
+	addr := fs.String("addr", "localhost:9000", "address to listen on")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s serve [flags]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	checkAnalysisFlags()
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Listening on %s\n", listener.Addr())
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			warnf("%s", err)
+			continue
+		}
+		// Connections are handled one at a time since they share the test state
+		err = processEvents(conn, warnEventError)
+		_ = conn.Close()
+		if err == nil {
+			fmt.Printf("Results from %s:\n", conn.RemoteAddr())
+			err = printResults()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", conn.RemoteAddr(), err)
+		}
+		resetState()
+	}
+}
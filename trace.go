@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"strconv"
+	"unicode/utf8"
+)
+
+// traceFile and traceWriter are the -trace-csv file and the writer of its rows, while events are being processed.
+var (
+	traceFile   *os.File
+	traceWriter *csv.Writer
+)
+
+// newCSVWriter returns a CSV writer that separates fields with the -csv-delimiter.
+func newCSVWriter(w io.Writer) *csv.Writer {
+	writer := csv.NewWriter(w)
+	writer.Comma, _ = utf8.DecodeRuneInString(csvDelimiter)
+	return writer
+}
+
+// openTrace creates the -trace-csv file and writes its header.
+func openTrace() error {
+	file, err := os.Create(traceCSV)
+	if err != nil {
+		return err
+	}
+	traceFile = file
+	traceWriter = newCSVWriter(file)
+	return traceWriter.Write([]string{"seconds", "action", "package", "test", "active_tests", "adjusted_seconds"})
+}
+
+// traceEvent writes the state after an event to the -trace-csv file: the number of active tests and the adjusted
+// execution time the test of the event has accumulated so far.
+func traceEvent(event Event) {
+	adjusted := ""
+	if test, ok := allTests[event.Test]; ok && event.Test != "" {
+		adjusted = strconv.FormatFloat(test.AdjustedExecutionTime.Seconds(), 'f', 6, 64)
+	}
+	// Write errors are reported by closeTrace
+	_ = traceWriter.Write([]string{
+		strconv.FormatFloat(event.Time.Sub(firstEventTime).Seconds(), 'f', 6, 64),
+		event.Action,
+		event.Package,
+		event.Test,
+		strconv.FormatUint(activeTests, 10),
+		adjusted,
+	})
+}
+
+// closeTrace flushes and closes the -trace-csv file.
+func closeTrace() error {
+	traceWriter.Flush()
+	if err := traceWriter.Error(); err != nil {
+		_ = traceFile.Close()
+		return err
+	}
+	return traceFile.Close()
+}
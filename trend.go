@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// trendBarWidth is the width of the bar that plots the longest adjusted time in the trend.
+const trendBarWidth = 40
+
+// trendPoint is the adjusted time of a test in one snapshot.
+type trendPoint struct {
+	snapshot string
+	name     string
+	adjusted time.Duration
+}
+
+// trend prints how the adjusted time of a test changed across the JSON reports saved in a directory, oldest first.
+func trend(args []string) {
+	fs := flag.NewFlagSet("trend", flag.ExitOnError)
+	dir := fs.String("dir", "", "directory of JSON reports, whose file names sort from oldest to newest")
+	test := fs.String("test", "", "test to show the trend of")
+	last := fs.Int("last", 0, "only use the last this many reports, or all if 0")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s trend -dir snapshots/ -test TestX [flags]\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "The reports are the output of -format json or -report-file.")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if *dir == "" || *test == "" || fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(*dir, "*.json"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	sort.Strings(paths)
+	if *last > 0 && len(paths) > *last {
+		paths = paths[len(paths)-*last:]
+	}
+	if len(paths) == 0 {
+		fmt.Fprintf(os.Stderr, "no JSON reports in %s\n", *dir)
+		os.Exit(1)
+	}
+
+	var points []trendPoint
+	var longest time.Duration
+	for _, path := range paths {
+		times, err := loadAdjustedTimes(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		// The times are keyed by package and test name, and the test may be in several packages
+		names := make([]string, 0, 1)
+		for name := range times {
+			if strings.HasSuffix(name, " "+*test) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		snapshot := strings.TrimSuffix(filepath.Base(path), ".json")
+		if len(names) == 0 {
+			points = append(points, trendPoint{snapshot: snapshot, adjusted: -1})
+		}
+		for _, name := range names {
+			points = append(points, trendPoint{snapshot: snapshot, name: name, adjusted: times[name]})
+			longest = max(longest, times[name])
+		}
+	}
+
+	for _, point := range points {
+		if point.adjusted < 0 {
+			fmt.Printf("%s: not run\n", point.snapshot)
+			continue
+		}
+		bar := 0
+		if longest > 0 {
+			bar = int(float64(point.adjusted) / float64(longest) * trendBarWidth)
+		}
+		fmt.Printf("%s %s: %s %s\n", point.snapshot, point.name, point.adjusted.Round(time.Millisecond),
+			strings.Repeat("#", bar))
+	}
+}
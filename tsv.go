@@ -0,0 +1,28 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// writeTSV writes all tests, in the given order, as tab-separated values with a header, with durations in the unit of
+// JSON output, for cut and awk.
+func writeTSV(w io.Writer, tests []*RunningTest) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = '\t'
+	unit := jsonUnitName()
+	// Write errors are reported by Flush
+	_ = writer.Write([]string{"package", "test", "adjusted_" + unit, "total_" + unit, "parallel"})
+	for _, test := range tests {
+		_ = writer.Write([]string{
+			test.Package,
+			test.Name,
+			strconv.FormatFloat(jsonDuration(test.AdjustedExecutionTime), 'f', -1, 64),
+			strconv.FormatFloat(jsonDuration(test.TotalExecutionTime), 'f', -1, 64),
+			strconv.FormatFloat(parallelism(test), 'f', 2, 64),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}